@@ -105,6 +105,9 @@ var RunJava = &cobra.Command{
 	Long:  `Runs a Java process with Radish. It automatically detects CGroup limits and some common flags`,
 	Args:  cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
+		if isDryRun(cmd) {
+			radish.DryRunJava(args)
+		}
 		radish.RunRadish(args)
 	},
 }
@@ -133,7 +136,15 @@ var RunNginx = &cobra.Command{
 			logrus.Fatalf("Could not read value checkRotateAfter: %v", err)
 		}
 
-		radish.RunNginx(nginxPath, rotateLogsAfterSize, checkRotateAfter)
+		if isDryRun(cmd) {
+			radish.DryRunNginx(args, nginxPath)
+		}
+		radishConfigPath, err := cmd.Flags().GetString("radishConfigPath")
+		if err != nil {
+			logrus.Fatalf("Could not read value radishConfigPath: %v", err)
+		}
+
+		radish.RunNginx(nginxPath, radishConfigPath, rotateLogsAfterSize, checkRotateAfter)
 	},
 }
 
@@ -174,7 +185,35 @@ var RunNodeJS = &cobra.Command{
 			}
 			stdoutFileRotateSize = stdoutFileRotateSizeInt
 		}
-		radish.RunNodeJS(mainJavascriptFile, stdoutLogLocation, stdoutLogFile, stdoutFileRotateSize)
+		if isDryRun(cmd) {
+			radish.DryRunNodeJS(args, mainJavascriptFile)
+		}
+		radishConfigPath, err := cmd.Flags().GetString("radishConfigPath")
+		if err != nil {
+			logrus.Fatalf("Could not read value radishConfigPath: %v", err)
+		}
+		radish.RunNodeJS(mainJavascriptFile, radishConfigPath, stdoutLogLocation, stdoutLogFile, stdoutFileRotateSize)
+	},
+}
+
+// Run :
+var Run = &cobra.Command{
+	Use:   "run -- <cmd> [args...]",
+	Short: "Runs any command with Radish",
+	Long: `Runs any command with Radish as PID 1, like tini or dumb-init. Radish forwards signals, reaps zombie
+	processes and rewrites the exit code of the command.
+
+	Example usage: radish run --exitCodeMapping 143=0 -- python app.py`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if isDryRun(cmd) {
+			radish.DryRunCommand(args)
+		}
+		exitCodeMapping, err := cmd.Flags().GetString("exitCodeMapping")
+		if err != nil {
+			logrus.Fatalf("Could not read value exitCodeMapping: %v", err)
+		}
+		radish.RunCommand(args, exitCodeMapping)
 	},
 }
 
@@ -188,3 +227,74 @@ var PrintClasspath = &cobra.Command{
 		radish.PrintRadishCP(args)
 	},
 }
+
+// ExplainJava :
+var ExplainJava = &cobra.Command{
+	Use:   "explainJava",
+	Short: "Explains the Java command line Radish will use with java application",
+	Long: `Explains the Java command line Radish will use with java application, without starting it.
+	Lists the argument modificators that were applied, the environment variables and cgroup limits they were
+	triggered by, and where each argument in the final command line came from.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := cmd.Flags().GetString("output")
+		if err != nil {
+			logrus.Fatalf("Could not read value output: %v", err)
+		}
+		radish.ExplainJava(args, output)
+	},
+}
+
+// ValidateDescriptor :
+var ValidateDescriptor = &cobra.Command{
+	Use:   "validateDescriptor [descriptor...]",
+	Short: "Validates radish descriptors against their JSON Schema",
+	Long: `Validates radish descriptors, and prints file:line:column for each unknown field, type mismatch or syntax error.
+	Exits with 1 if any descriptor has problems. The type is detected from the content unless --type is set.
+	Without arguments the radish descriptor is located like runJava does.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		descriptorType, err := cmd.Flags().GetString("type")
+		if err != nil {
+			logrus.Fatalf("Could not read value type: %v", err)
+		}
+		printSchema, err := cmd.Flags().GetBool("print-schema")
+		if err != nil {
+			logrus.Fatalf("Could not read value print-schema: %v", err)
+		}
+		if printSchema {
+			radish.PrintDescriptorSchema(descriptorType)
+			return
+		}
+		radish.ValidateDescriptor(args, descriptorType)
+	},
+}
+
+// MigrateDescriptor :
+var MigrateDescriptor = &cobra.Command{
+	Use:   "migrateDescriptor [descriptor]",
+	Short: "Upgrades a Java radish descriptor to the newest version",
+	Long: `Upgrades a Java radish descriptor to the newest version, and prints it. Descriptors without Version are version 1.
+	Without arguments the radish descriptor is located like runJava does.
+
+	Example usage: radish migrateDescriptor --in-place radish.json`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inPlace, err := cmd.Flags().GetBool("in-place")
+		if err != nil {
+			logrus.Fatalf("Could not read value in-place: %v", err)
+		}
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			logrus.Fatalf("Could not read value format: %v", err)
+		}
+		radish.MigrateDescriptor(args, inPlace, format)
+	},
+}
+
+func isDryRun(cmd *cobra.Command) bool {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		logrus.Fatalf("Could not read value dry-run: %v", err)
+	}
+	return dryRun
+}
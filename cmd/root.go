@@ -23,6 +23,8 @@ var mainJavascriptFile string
 var stdoutLogLocation string
 var stdoutLogFile string
 
+const dryRunUsage = "Print the binary, arguments, environment, working directory and descriptor that would be used, without starting the process"
+
 // Execute :
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -34,7 +36,16 @@ func Execute() {
 func init() {
 	cobra.OnInitialize(initConfig)
 	rootCmd.AddCommand(radish.RunJava)
+	radish.RunJava.Flags().Bool("dry-run", false, dryRunUsage)
 	rootCmd.AddCommand(radish.PrintClasspath)
+	rootCmd.AddCommand(radish.ExplainJava)
+	radish.ExplainJava.Flags().String("output", "text", "Output format, text or json")
+	rootCmd.AddCommand(radish.ValidateDescriptor)
+	radish.ValidateDescriptor.Flags().String("type", "", "Descriptor type, java or web. Detected from the content when not set")
+	radish.ValidateDescriptor.Flags().Bool("print-schema", false, "Print the JSON Schema of --type instead of validating")
+	rootCmd.AddCommand(radish.MigrateDescriptor)
+	radish.MigrateDescriptor.Flags().Bool("in-place", false, "Overwrite the descriptor instead of printing it. Can not be combined with a --format that changes the format")
+	radish.MigrateDescriptor.Flags().String("format", "", "Write the descriptor as json, yaml or toml. Keeps the format of the descriptor when not set")
 
 	rootCmd.AddCommand(radish.GenerateNginxConfiguration)
 	radish.GenerateNginxConfiguration.Flags().StringVarP(&openshiftConfigPath, "radishConfigPath", "", "", "path to the radish config file")
@@ -44,14 +55,24 @@ func init() {
 	radish.RunNginx.Flags().StringVarP(&nginxPath, "nginxPath", "", "", "The nginxPath is the location (including file name) where the config file is stored.")
 	radish.RunNginx.Flags().Int("rotateLogsAfterSize", 50, "Rotate logs when log size is above this value. Value is in MB")
 	radish.RunNginx.Flags().Int("checkRotateAfter", 1000, "The interval in which we check log rotation")
+	radish.RunNginx.Flags().Bool("dry-run", false, dryRunUsage)
+	radish.RunNginx.Flags().String("radishConfigPath", "", "Optional path to the radish config file, used for web.exitCodeMapping")
 
 	rootCmd.AddCommand(radish.RunNodeJS)
 	radish.RunNodeJS.Flags().StringVarP(&mainJavascriptFile, "mainJavascriptFile", "", "", "The file name of the nodeJS program to run")
 	radish.RunNodeJS.Flags().StringVarP(&stdoutLogLocation, "stdoutLogLocation", "", "/u01/logs", "Where the log is put - default /u01/logs")
 	radish.RunNodeJS.Flags().StringVarP(&stdoutLogFile, "stdoutLogFile", "", "nodejs_stdout.log", "The file name for the file the nodejs stdout log ends up in. Default nodejs_stdout.log")
 	radish.RunNodeJS.Flags().Int("stdoutFileRotateSize", 50, "The maximum size of the log file before log rotation - default max file size is 50MB")
+	radish.RunNodeJS.Flags().Bool("dry-run", false, dryRunUsage)
+	radish.RunNodeJS.Flags().String("radishConfigPath", "", "Optional path to the radish config file, used for web.exitCodeMapping")
 
 	rootCmd.AddCommand(radish.GenerateEnvScript)
+
+	rootCmd.AddCommand(radish.Run)
+	// Flags after the command belong to the command
+	radish.Run.Flags().SetInterspersed(false)
+	radish.Run.Flags().String("exitCodeMapping", "", "Comma separated list of exit codes to rewrite, like 143=0,3=0")
+	radish.Run.Flags().Bool("dry-run", false, dryRunUsage)
 }
 
 // initConfig reads in config file and ENV variables if set.
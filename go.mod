@@ -2,6 +2,7 @@ module github.com/skatteetaten/radish
 
 // direct dependencies:
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/drone/envsubst v1.0.3
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
 	github.com/magiconair/properties v1.8.6
@@ -10,6 +11,8 @@ require (
 	github.com/sirupsen/logrus v1.9.0
 	github.com/spf13/cobra v1.5.0
 	github.com/stretchr/testify v1.7.0
+	golang.org/x/sys v0.0.0-20220804214406-8e32c043e418
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require git.aurora.skead.no/apsi/logwriter v0.0.2
@@ -19,8 +22,6 @@ require (
 	github.com/inconshreveable/mousetrap v1.0.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.0.0-20220804214406-8e32c043e418 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
 
 go 1.19
@@ -0,0 +1,348 @@
+package crash
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	crashDirEnv        = "RADISH_CRASH_DIR"
+	crashDirMaxSizeEnv = "RADISH_CRASH_DIR_MAX_SIZE"
+	crashRetentionEnv  = "RADISH_CRASH_RETENTION"
+
+	defaultMaxSizeInMB = 1024
+	defaultRetention   = 7 * 24 * time.Hour
+
+	// Marker : the prefix of every line of a crash report written to stdout, so it can be found in the logs
+	Marker = "[radish-crash]"
+)
+
+// Report : the crash artifacts that were found after a crash. Paths are empty when an artifact was not found.
+type Report struct {
+	Pid      int
+	HsErr    string
+	HeapDump string
+	Core     string
+}
+
+// Artifacts : the paths of the artifacts that were found
+func (r Report) Artifacts() []string {
+	artifacts := make([]string, 0, 3)
+	for _, artifact := range []string{r.HsErr, r.HeapDump, r.Core} {
+		if artifact != "" {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	return artifacts
+}
+
+// Hook : called with the report after the artifacts are collected
+type Hook func(Report) error
+
+// Collector : finds the hs_err log, heap dump and core dump of a crashed JVM, writes the hs_err log to out,
+// and copies the artifacts to a persistent directory if one is configured
+type Collector struct {
+	errorFile    string
+	heapDumpPath string
+	searchDirs   []string
+	out          io.Writer
+
+	persistentDir string
+	maxSizeInMB   int64
+	retention     time.Duration
+	now           func() time.Time
+
+	hooks []Hook
+}
+
+// NewCollector : javaArgs are the arguments java was started with. They are used to find -XX:ErrorFile and
+// -XX:HeapDumpPath. The persistent directory, size cap and retention are read from the environment.
+func NewCollector(javaArgs []string, env func(string) (string, bool)) *Collector {
+	c := &Collector{
+		searchDirs:  []string{".", os.TempDir()},
+		out:         os.Stdout,
+		maxSizeInMB: defaultMaxSizeInMB,
+		retention:   defaultRetention,
+		now:         time.Now,
+	}
+	for _, arg := range javaArgs {
+		if strings.HasPrefix(arg, "-XX:ErrorFile=") {
+			c.errorFile = strings.TrimPrefix(arg, "-XX:ErrorFile=")
+		}
+		if strings.HasPrefix(arg, "-XX:HeapDumpPath=") {
+			c.heapDumpPath = strings.TrimPrefix(arg, "-XX:HeapDumpPath=")
+		}
+	}
+
+	if dir, exists := env(crashDirEnv); exists {
+		c.persistentDir = dir
+	}
+	if maxSize, exists := env(crashDirMaxSizeEnv); exists {
+		parsed, err := strconv.ParseInt(maxSize, 10, 64)
+		if err != nil {
+			logrus.Warnf("Could not parse %s %s (%s). Using %d MB", crashDirMaxSizeEnv, maxSize, err, defaultMaxSizeInMB)
+		} else {
+			c.maxSizeInMB = parsed
+		}
+	}
+	if retention, exists := env(crashRetentionEnv); exists {
+		parsed, err := time.ParseDuration(retention)
+		if err != nil {
+			logrus.Warnf("Could not parse %s %s (%s). Using %s", crashRetentionEnv, retention, err, defaultRetention)
+		} else {
+			c.retention = parsed
+		}
+	}
+
+	uploader, err := NewUploaderFromEnv(env)
+	if err != nil {
+		logrus.Warnf("Crash artifacts will not be uploaded: %s", err)
+	} else if uploader != nil {
+		c.AddHook(uploader.Upload)
+	}
+	return c
+}
+
+// AddHook : adds a hook that is called after the artifacts are collected
+func (c *Collector) AddHook(hook Hook) {
+	c.hooks = append(c.hooks, hook)
+}
+
+// Collect : finds the artifacts of the crashed process, writes the hs_err log to out, copies the artifacts
+// and calls the hooks
+func (c *Collector) Collect(pid int) Report {
+	report := Report{
+		Pid:      pid,
+		HsErr:    c.findHsErr(pid),
+		HeapDump: c.findHeapDump(pid),
+		Core:     c.findCore(pid),
+	}
+	if report.HsErr != "" {
+		if err := c.stream(report.HsErr); err != nil {
+			logrus.Errorf("Error reading crash report %s: %s", report.HsErr, err)
+		}
+	} else if report.HeapDump == "" {
+		// -XX:+ExitOnOutOfMemoryError exits without an hs_err log, so it is only missed when there is no heap dump
+		logrus.Errorf("Could not find crash report hs_err_pid%d.log", pid)
+	}
+	if report.HeapDump != "" {
+		logrus.Infof("Found heap dump %s", report.HeapDump)
+	}
+	if report.Core != "" {
+		logrus.Infof("Found core dump %s", report.Core)
+	}
+	if c.persistentDir != "" {
+		if err := c.persist(report); err != nil {
+			logrus.Errorf("Could not copy crash artifacts to %s: %s", c.persistentDir, err)
+		}
+	}
+	for _, hook := range c.hooks {
+		if err := hook(report); err != nil {
+			logrus.Errorf("Crash hook failed: %s", err)
+		}
+	}
+	return report
+}
+
+func (c *Collector) findHsErr(pid int) string {
+	candidates := make([]string, 0)
+	if c.errorFile != "" {
+		candidates = append(candidates, substitutePid(c.errorFile, pid))
+	}
+	// The JVM writes to the working directory, and to the temp directory when that fails
+	for _, dir := range c.searchDirs {
+		candidates = append(candidates, filepath.Join(dir, fmt.Sprintf("hs_err_pid%d.log", pid)))
+	}
+	return firstExisting(candidates)
+}
+
+func (c *Collector) findHeapDump(pid int) string {
+	name := fmt.Sprintf("java_pid%d.hprof", pid)
+	candidates := make([]string, 0)
+	if c.heapDumpPath != "" {
+		heapDumpPath := substitutePid(c.heapDumpPath, pid)
+		if info, err := os.Stat(heapDumpPath); err == nil && info.IsDir() {
+			candidates = append(candidates, filepath.Join(heapDumpPath, name))
+		} else {
+			candidates = append(candidates, heapDumpPath)
+		}
+	}
+	for _, dir := range c.searchDirs {
+		candidates = append(candidates, filepath.Join(dir, name))
+	}
+	return firstExisting(candidates)
+}
+
+// findCore : with -XX:+CreateCoredumpOnCrash the name of the core depends on the kernel core_pattern.
+// We look for the common defaults.
+func (c *Collector) findCore(pid int) string {
+	candidates := make([]string, 0)
+	for _, dir := range c.searchDirs {
+		candidates = append(candidates, filepath.Join(dir, fmt.Sprintf("core.%d", pid)), filepath.Join(dir, "core"))
+	}
+	return firstExisting(candidates)
+}
+
+// stream : writes the file line by line, with the marker and file name as prefix
+func (c *Collector) stream(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	prefix := fmt.Sprintf("%s %s:", Marker, filepath.Base(path))
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(c.out, prefix, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// persist : copies the artifacts to a new directory in the persistent directory. Old crashes are removed
+// when they are older than the retention, or to make room within the size cap. Artifacts that do not fit are skipped.
+func (c *Collector) persist(report Report) error {
+	if err := os.MkdirAll(c.persistentDir, 0755); err != nil {
+		return err
+	}
+	c.removeExpired()
+
+	maxSize := c.maxSizeInMB * 1024 * 1024
+	target := filepath.Join(c.persistentDir, fmt.Sprintf("%s-pid%d", c.now().UTC().Format("20060102T150405Z"), report.Pid))
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return err
+	}
+	for _, artifact := range report.Artifacts() {
+		info, err := os.Stat(artifact)
+		if err != nil {
+			return err
+		}
+		if info.Size() > maxSize {
+			logrus.Warnf("%s is %d bytes, which is larger than %s. Not copying it", artifact, info.Size(), crashDirMaxSizeEnv)
+			continue
+		}
+		c.makeRoom(maxSize-info.Size(), target)
+		if err := copyFile(artifact, filepath.Join(target, filepath.Base(artifact))); err != nil {
+			return err
+		}
+		logrus.Infof("Copied %s to %s", artifact, target)
+	}
+	return nil
+}
+
+type crashDir struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// crashDirs : the crashes in the persistent directory, oldest first
+func (c *Collector) crashDirs() []crashDir {
+	entries, err := os.ReadDir(c.persistentDir)
+	if err != nil {
+		return []crashDir{}
+	}
+	dirs := make([]crashDir, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(c.persistentDir, entry.Name())
+		dirs = append(dirs, crashDir{path: path, modTime: info.ModTime(), size: dirSize(path)})
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].modTime.Before(dirs[j].modTime)
+	})
+	return dirs
+}
+
+func (c *Collector) removeExpired() {
+	for _, dir := range c.crashDirs() {
+		if c.now().Sub(dir.modTime) > c.retention {
+			logrus.Infof("Removing %s, which is older than %s", dir.path, c.retention)
+			removeDir(dir.path)
+		}
+	}
+}
+
+// makeRoom : removes the oldest crashes, except current, until the total size is at most maxSize
+func (c *Collector) makeRoom(maxSize int64, current string) {
+	dirs := c.crashDirs()
+	total := int64(0)
+	for _, dir := range dirs {
+		total += dir.size
+	}
+	for _, dir := range dirs {
+		if total <= maxSize {
+			return
+		}
+		if dir.path == current {
+			continue
+		}
+		logrus.Infof("Removing %s to stay within %d MB", dir.path, c.maxSizeInMB)
+		removeDir(dir.path)
+		total -= dir.size
+	}
+}
+
+func removeDir(path string) {
+	if err := os.RemoveAll(path); err != nil {
+		logrus.Errorf("Could not remove %s: %s", path, err)
+	}
+}
+
+func dirSize(path string) int64 {
+	size := int64(0)
+	_ = filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func copyFile(source string, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return errors.Wrapf(err, "Could not copy %s", source)
+	}
+	return out.Close()
+}
+
+// substitutePid : the JVM replaces %p with the pid in file names, and %% with %
+func substitutePid(path string, pid int) string {
+	return strings.NewReplacer("%p", strconv.Itoa(pid), "%%", "%").Replace(path)
+}
+
+func firstExisting(candidates []string) string {
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package crash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func noEnv(string) (string, bool) {
+	return "", false
+}
+
+func envOf(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, exists := values[key]
+		return value, exists
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func newTestCollector(t *testing.T, javaArgs []string, env func(string) (string, bool)) (*Collector, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	c := NewCollector(javaArgs, env)
+	c.searchDirs = []string{t.TempDir()}
+	c.out = out
+	return c, out
+}
+
+func TestCollectStreamsHsErrFromErrorFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "hs_err_42.log"), "# A fatal error has been detected\n# SIGSEGV\n")
+	c, out := newTestCollector(t, []string{"-XX:ErrorFile=" + dir + "/hs_err_%p.log"}, noEnv)
+
+	report := c.Collect(42)
+
+	assert.Equal(t, filepath.Join(dir, "hs_err_42.log"), report.HsErr)
+	assert.Equal(t, "[radish-crash] hs_err_42.log: # A fatal error has been detected\n"+
+		"[radish-crash] hs_err_42.log: # SIGSEGV\n", out.String())
+}
+
+func TestCollectFindsArtifactsInSearchDirs(t *testing.T) {
+	c, _ := newTestCollector(t, []string{}, noEnv)
+	tmp := t.TempDir()
+	c.searchDirs = append(c.searchDirs, tmp)
+	writeFile(t, filepath.Join(tmp, "hs_err_pid7.log"), "crash")
+	writeFile(t, filepath.Join(tmp, "java_pid7.hprof"), "heap")
+	writeFile(t, filepath.Join(tmp, "core.7"), "core")
+
+	report := c.Collect(7)
+
+	assert.Equal(t, filepath.Join(tmp, "hs_err_pid7.log"), report.HsErr)
+	assert.Equal(t, filepath.Join(tmp, "java_pid7.hprof"), report.HeapDump)
+	assert.Equal(t, filepath.Join(tmp, "core.7"), report.Core)
+}
+
+func TestCollectFindsHeapDumpInHeapDumpPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "java_pid7.hprof"), "heap")
+	file := filepath.Join(t.TempDir(), "heap.hprof")
+	writeFile(t, file, "heap")
+
+	c, _ := newTestCollector(t, []string{"-XX:HeapDumpPath=" + dir}, noEnv)
+	assert.Equal(t, filepath.Join(dir, "java_pid7.hprof"), c.Collect(7).HeapDump)
+
+	c, _ = newTestCollector(t, []string{"-XX:HeapDumpPath=" + file}, noEnv)
+	assert.Equal(t, file, c.Collect(7).HeapDump)
+}
+
+func TestCollectWithoutArtifacts(t *testing.T) {
+	c, out := newTestCollector(t, []string{}, noEnv)
+
+	report := c.Collect(7)
+
+	assert.Empty(t, report.Artifacts())
+	assert.Empty(t, out.String())
+}
+
+func TestCollectCopiesArtifactsToPersistentDir(t *testing.T) {
+	persistent := t.TempDir()
+	c, _ := newTestCollector(t, []string{}, envOf(map[string]string{"RADISH_CRASH_DIR": persistent}))
+	writeFile(t, filepath.Join(c.searchDirs[0], "hs_err_pid7.log"), "crash")
+	writeFile(t, filepath.Join(c.searchDirs[0], "java_pid7.hprof"), "heap")
+	c.now = func() time.Time {
+		return time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	}
+
+	c.Collect(7)
+
+	dat, err := os.ReadFile(filepath.Join(persistent, "20221001T120000Z-pid7", "java_pid7.hprof"))
+	assert.NoError(t, err)
+	assert.Equal(t, "heap", string(dat))
+	assert.FileExists(t, filepath.Join(persistent, "20221001T120000Z-pid7", "hs_err_pid7.log"))
+}
+
+func TestCollectRemovesExpiredCrashes(t *testing.T) {
+	persistent := t.TempDir()
+	expired := filepath.Join(persistent, "expired")
+	recent := filepath.Join(persistent, "recent")
+	writeFile(t, filepath.Join(expired, "hs_err_pid1.log"), "crash")
+	writeFile(t, filepath.Join(recent, "hs_err_pid2.log"), "crash")
+	old := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(expired, old, old))
+
+	c, _ := newTestCollector(t, []string{}, envOf(map[string]string{
+		"RADISH_CRASH_DIR":       persistent,
+		"RADISH_CRASH_RETENTION": "24h",
+	}))
+	writeFile(t, filepath.Join(c.searchDirs[0], "hs_err_pid7.log"), "crash")
+
+	c.Collect(7)
+
+	assert.NoDirExists(t, expired)
+	assert.DirExists(t, recent)
+}
+
+func TestCollectStaysWithinSizeCap(t *testing.T) {
+	persistent := t.TempDir()
+	megabyte := strings.Repeat("x", 1024*1024)
+	oldest := filepath.Join(persistent, "oldest")
+	writeFile(t, filepath.Join(oldest, "java_pid1.hprof"), megabyte)
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(oldest, old, old))
+
+	c, _ := newTestCollector(t, []string{}, envOf(map[string]string{
+		"RADISH_CRASH_DIR":          persistent,
+		"RADISH_CRASH_DIR_MAX_SIZE": "1",
+	}))
+	writeFile(t, filepath.Join(c.searchDirs[0], "java_pid7.hprof"), megabyte)
+	writeFile(t, filepath.Join(c.searchDirs[0], "core.7"), megabyte+"x")
+
+	c.Collect(7)
+
+	assert.NoDirExists(t, oldest)
+	matches, _ := filepath.Glob(filepath.Join(persistent, "*-pid7", "*"))
+	assert.Len(t, matches, 1)
+	assert.Equal(t, "java_pid7.hprof", filepath.Base(matches[0]))
+}
+
+func TestSubstitutePid(t *testing.T) {
+	assert.Equal(t, "/tmp/hs_err_42.log", substitutePid("/tmp/hs_err_%p.log", 42))
+	assert.Equal(t, "/tmp/100%_42", substitutePid("/tmp/100%%_%p", 42))
+}
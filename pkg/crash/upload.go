@@ -0,0 +1,191 @@
+package crash
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	uploadEndpointEnv  = "RADISH_CRASH_UPLOAD_ENDPOINT"
+	uploadBucketEnv    = "RADISH_CRASH_UPLOAD_BUCKET"
+	uploadAccessKeyEnv = "RADISH_CRASH_UPLOAD_ACCESS_KEY"
+	uploadSecretKeyEnv = "RADISH_CRASH_UPLOAD_SECRET_KEY"
+	uploadRegionEnv    = "RADISH_CRASH_UPLOAD_REGION"
+	uploadPrefixEnv    = "RADISH_CRASH_UPLOAD_PREFIX"
+
+	defaultUploadRegion = "us-east-1"
+)
+
+// Uploader : uploads the heap dump and hs_err log of a crash as one compressed archive to an S3 compatible
+// object store, like MinIO. Path style addressing is used, so the endpoint does not need a wildcard certificate.
+type Uploader struct {
+	endpoint  *url.URL
+	bucket    string
+	accessKey string
+	secretKey string
+	region    string
+	prefix    string
+	metadata  map[string]string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewUploaderFromEnv : returns nil when RADISH_CRASH_UPLOAD_ENDPOINT is not set
+func NewUploaderFromEnv(env func(string) (string, bool)) (*Uploader, error) {
+	endpoint, exists := env(uploadEndpointEnv)
+	if !exists || endpoint == "" {
+		return nil, nil
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil || endpointURL.Host == "" {
+		return nil, errors.Errorf("%s %s is not a valid url", uploadEndpointEnv, endpoint)
+	}
+	lookup := func(key string, fallback string) string {
+		if value, exists := env(key); exists && value != "" {
+			return value
+		}
+		return fallback
+	}
+	u := &Uploader{
+		endpoint:  endpointURL,
+		bucket:    lookup(uploadBucketEnv, ""),
+		accessKey: lookup(uploadAccessKeyEnv, ""),
+		secretKey: lookup(uploadSecretKeyEnv, ""),
+		region:    lookup(uploadRegionEnv, defaultUploadRegion),
+		prefix:    strings.Trim(lookup(uploadPrefixEnv, ""), "/"),
+		metadata: map[string]string{
+			"app-name":      lookup("APP_NAME", "unknown"),
+			"pod-name":      lookup("POD_NAME", "unknown"),
+			"pod-namespace": lookup("POD_NAMESPACE", "unknown"),
+		},
+		client: &http.Client{Timeout: 30 * time.Minute},
+		now:    time.Now,
+	}
+	for key, value := range map[string]string{uploadBucketEnv: u.bucket, uploadAccessKeyEnv: u.accessKey, uploadSecretKeyEnv: u.secretKey} {
+		if value == "" {
+			return nil, errors.Errorf("%s is set, but %s is missing", uploadEndpointEnv, key)
+		}
+	}
+	return u, nil
+}
+
+// Upload : compresses the heap dump and hs_err log of the report, and uploads them
+func (u *Uploader) Upload(report Report) error {
+	artifacts := make([]string, 0, 2)
+	for _, artifact := range []string{report.HsErr, report.HeapDump} {
+		if artifact != "" {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	if len(artifacts) == 0 {
+		logrus.Info("No heap dump or hs_err log to upload")
+		return nil
+	}
+
+	archive, err := os.CreateTemp("", "radish-crash-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+	if err := writeArchive(archive, artifacts); err != nil {
+		return err
+	}
+	info, err := archive.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := u.objectKey(report)
+	if err := u.put(key, archive, info.Size()); err != nil {
+		return err
+	}
+	logrus.Infof("Uploaded %s to %s/%s", strings.Join(artifacts, ", "), u.bucket, key)
+	return nil
+}
+
+// objectKey : [prefix/]namespace/app/pod-time-pidN.tar.gz
+func (u *Uploader) objectKey(report Report) string {
+	name := fmt.Sprintf("%s-%s-pid%d.tar.gz", u.metadata["pod-name"], u.now().UTC().Format("20060102T150405Z"), report.Pid)
+	parts := []string{u.metadata["pod-namespace"], u.metadata["app-name"], name}
+	if u.prefix != "" {
+		parts = append([]string{u.prefix}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+func (u *Uploader) put(key string, body io.Reader, size int64) error {
+	target := *u.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + u.bucket + "/" + key
+	req, err := http.NewRequest(http.MethodPut, target.String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/gzip")
+	for name, value := range u.metadata {
+		req.Header.Set("X-Amz-Meta-"+name, value)
+	}
+	signV4(req, u.accessKey, u.secretKey, u.region, unsignedPayload, u.now())
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Could not upload crash artifacts")
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return errors.Errorf("Could not upload crash artifacts. Got %s: %s", res.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+func writeArchive(w io.Writer, files []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		if err := addToArchive(tw, file); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addToArchive(tw *tar.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(file)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return errors.Wrapf(err, "Could not compress %s", file)
+}
@@ -0,0 +1,112 @@
+package crash
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uploadedObject struct {
+	method string
+	path   string
+	header http.Header
+	files  map[string]string
+}
+
+// objectStoreStub : accepts PUT object requests like MinIO, and records the uploaded archive
+func objectStoreStub(t *testing.T, status int) (*httptest.Server, *[]uploadedObject) {
+	uploads := make([]uploadedObject, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upload := uploadedObject{method: r.Method, path: r.URL.Path, header: r.Header, files: map[string]string{}}
+		gz, err := gzip.NewReader(r.Body)
+		if assert.NoError(t, err) {
+			tr := tar.NewReader(gz)
+			for {
+				header, err := tr.Next()
+				if err != nil {
+					break
+				}
+				content, _ := io.ReadAll(tr)
+				upload.files[header.Name] = string(content)
+			}
+		}
+		uploads = append(uploads, upload)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &uploads
+}
+
+func uploadEnv(endpoint string) map[string]string {
+	return map[string]string{
+		"RADISH_CRASH_UPLOAD_ENDPOINT":   endpoint,
+		"RADISH_CRASH_UPLOAD_BUCKET":     "crashes",
+		"RADISH_CRASH_UPLOAD_ACCESS_KEY": "minio",
+		"RADISH_CRASH_UPLOAD_SECRET_KEY": "minio123",
+		"APP_NAME":                       "myapp",
+		"POD_NAME":                       "myapp-1-abcde",
+		"POD_NAMESPACE":                  "aurora-test",
+	}
+}
+
+func TestCollectUploadsArtifacts(t *testing.T) {
+	server, uploads := objectStoreStub(t, http.StatusOK)
+	c, _ := newTestCollector(t, []string{}, envOf(uploadEnv(server.URL)))
+	writeFile(t, filepath.Join(c.searchDirs[0], "hs_err_pid7.log"), "crash")
+	writeFile(t, filepath.Join(c.searchDirs[0], "java_pid7.hprof"), "heap")
+	writeFile(t, filepath.Join(c.searchDirs[0], "core.7"), "core")
+
+	c.Collect(7)
+
+	assert.Len(t, *uploads, 1)
+	upload := (*uploads)[0]
+	assert.Equal(t, http.MethodPut, upload.method)
+	assert.Regexp(t, `^/crashes/aurora-test/myapp/myapp-1-abcde-\d{8}T\d{6}Z-pid7\.tar\.gz$`, upload.path)
+	assert.Equal(t, "myapp", upload.header.Get("X-Amz-Meta-App-Name"))
+	assert.Equal(t, "myapp-1-abcde", upload.header.Get("X-Amz-Meta-Pod-Name"))
+	assert.Equal(t, "aurora-test", upload.header.Get("X-Amz-Meta-Pod-Namespace"))
+	assert.Contains(t, upload.header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=minio/")
+	assert.Contains(t, upload.header.Get("Authorization"), "/us-east-1/s3/aws4_request")
+	assert.Equal(t, map[string]string{"hs_err_pid7.log": "crash", "java_pid7.hprof": "heap"}, upload.files)
+}
+
+func TestUploadFailsOnErrorResponse(t *testing.T) {
+	server, _ := objectStoreStub(t, http.StatusForbidden)
+	uploader, err := NewUploaderFromEnv(envOf(uploadEnv(server.URL)))
+	assert.NoError(t, err)
+	hsErr := filepath.Join(t.TempDir(), "hs_err_pid7.log")
+	writeFile(t, hsErr, "crash")
+
+	err = uploader.Upload(Report{Pid: 7, HsErr: hsErr})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
+
+func TestNewUploaderFromEnv(t *testing.T) {
+	uploader, err := NewUploaderFromEnv(noEnv)
+	assert.NoError(t, err)
+	assert.Nil(t, uploader)
+
+	env := uploadEnv("http://minio:9000")
+	delete(env, "RADISH_CRASH_UPLOAD_SECRET_KEY")
+	_, err = NewUploaderFromEnv(envOf(env))
+	assert.Error(t, err)
+
+	env = uploadEnv("http://minio:9000")
+	env["RADISH_CRASH_UPLOAD_PREFIX"] = "/crash/"
+	delete(env, "POD_NAME")
+	uploader, err = NewUploaderFromEnv(envOf(env))
+	assert.NoError(t, err)
+	uploader.now = func() time.Time {
+		return time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	}
+	assert.Equal(t, "crash/aurora-test/myapp/unknown-20221001T120000Z-pid7.tar.gz", uploader.objectKey(Report{Pid: 7}))
+}
@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// DescribeCmd : writes what would be executed for cmd, without starting it. The environment is shown as a diff
+// against the environment of radish, since that is what the process inherits when cmd.Env is not set.
+func DescribeCmd(w io.Writer, cmd *exec.Cmd, radishDescriptor string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	binary := cmd.Path
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		binary = fmt.Sprintf("%s (not found: %s)", cmd.Path, err)
+	}
+	fmt.Fprintf(tw, "Binary:\t%s\n", binary)
+	fmt.Fprintf(tw, "Arguments:\t%s\n", shellquote.Join(cmd.Args[1:]...))
+
+	dir := cmd.Dir
+	if dir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		dir = wd
+	}
+	fmt.Fprintf(tw, "Working directory:\t%s\n", dir)
+
+	if radishDescriptor == "" {
+		radishDescriptor = "<none>"
+	}
+	fmt.Fprintf(tw, "Descriptor:\t%s\n", radishDescriptor)
+
+	fmt.Fprintln(tw, "Environment:")
+	if cmd.Env == nil {
+		fmt.Fprintln(tw, "  inherited without changes")
+	}
+	for _, line := range EnvironmentDiff(os.Environ(), cmd.Env) {
+		fmt.Fprintf(tw, "  %s\n", line)
+	}
+	return tw.Flush()
+}
+
+// EnvironmentDiff : the variables that are added (+), removed (-) or changed (~) in env compared to base,
+// sorted by name. A nil env is inherited from base, and has no changes.
+func EnvironmentDiff(base []string, env []string) []string {
+	if env == nil {
+		return []string{}
+	}
+	before := environmentToMap(base)
+	after := environmentToMap(env)
+
+	diff := make([]string, 0)
+	for key, value := range after {
+		previous, exists := before[key]
+		if !exists {
+			diff = append(diff, fmt.Sprintf("+ %s=%s", key, value))
+		} else if previous != value {
+			diff = append(diff, fmt.Sprintf("~ %s=%s", key, value))
+		}
+	}
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			diff = append(diff, fmt.Sprintf("- %s", key))
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i][2:] < diff[j][2:]
+	})
+	return diff
+}
+
+// environmentToMap : later entries win, like they do for exec.Cmd
+func environmentToMap(env []string) map[string]string {
+	ret := make(map[string]string, len(env))
+	for _, entry := range env {
+		key, value, _ := strings.Cut(entry, "=")
+		ret[key] = value
+	}
+	return ret
+}
@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentDiff(t *testing.T) {
+	base := []string{"HOME=/u01", "PATH=/usr/bin", "REMOVED=true"}
+	env := []string{"HOME=/u01", "PATH=/usr/local/bin", "ADDED=1"}
+
+	assert.Equal(t, []string{"+ ADDED=1", "~ PATH=/usr/local/bin", "- REMOVED"}, EnvironmentDiff(base, env))
+}
+
+func TestEnvironmentDiffInheritedEnvironment(t *testing.T) {
+	assert.Empty(t, EnvironmentDiff([]string{"HOME=/u01"}, nil))
+}
+
+func TestDescribeCmd(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "echo 'hello world'")
+	cmd.Dir = "/tmp"
+	cmd.Env = append(os.Environ(), "RADISH_DRY_RUN_TEST=yes")
+
+	var out bytes.Buffer
+	err := DescribeCmd(&out, cmd, "testdata/radish.json")
+
+	assert.NoError(t, err)
+	assert.Regexp(t, `Binary:\s+/.*sh\n`, out.String())
+	assert.Contains(t, out.String(), `-c 'echo '\''hello world'\'`)
+	assert.Regexp(t, `Working directory:\s+/tmp\n`, out.String())
+	assert.Regexp(t, `Descriptor:\s+testdata/radish.json\n`, out.String())
+	assert.Contains(t, out.String(), "+ RADISH_DRY_RUN_TEST=yes")
+}
+
+func TestDescribeCmdWithMissingBinary(t *testing.T) {
+	cmd := exec.Command("radish-binary-that-does-not-exist")
+
+	var out bytes.Buffer
+	err := DescribeCmd(&out, cmd, "")
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "radish-binary-that-does-not-exist (not found")
+	assert.Regexp(t, `Descriptor:\s+<none>`, out.String())
+	assert.Contains(t, out.String(), "inherited without changes")
+}
@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/schema"
+)
+
+// exitCodeMappingEnv : overrides the exit code mapping from defaults and the descriptor
+const exitCodeMappingEnv = "RADISH_EXIT_CODE_MAPPING"
+
+// ExitCodeMapping : rewrites the exit code of the child to the exit code of radish
+type ExitCodeMapping map[int]int
+
+// ResolveExitCodeMapping : the defaults, overridden by the mapping in the descriptor, overridden by
+// RADISH_EXIT_CODE_MAPPING. An exit code can be mapped to itself to remove a default rewrite.
+func ResolveExitCodeMapping(defaults ExitCodeMapping, descriptorMapping map[string]int, env func(string) (string, bool)) (ExitCodeMapping, error) {
+	fromDescriptor := make(ExitCodeMapping)
+	for from, to := range descriptorMapping {
+		fromCode, err := parseExitCode(from)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid exitCodeMapping in descriptor")
+		}
+		toCode, err := parseExitCode(strconv.Itoa(to))
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid exitCodeMapping in descriptor")
+		}
+		fromDescriptor[fromCode] = toCode
+	}
+	fromEnv := make(ExitCodeMapping)
+	if value, exists := env(exitCodeMappingEnv); exists {
+		var err error
+		fromEnv, err = ParseExitCodeMapping(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid %s", exitCodeMappingEnv)
+		}
+	}
+	return defaults.Merge(fromDescriptor).Merge(fromEnv), nil
+}
+
+// LoadExitCodeMapping : the exit code mapping for nginx and nodejs, from web.exitCodeMapping in the openshift
+// config and RADISH_EXIT_CODE_MAPPING. The openshift config is optional, and only web.exitCodeMapping is read from it.
+func LoadExitCodeMapping(openshiftConfigPath string) (ExitCodeMapping, error) {
+	var openshiftConfig struct {
+		Web struct {
+			ExitCodeMapping map[string]int `json:"exitCodeMapping"`
+		} `json:"web"`
+	}
+	if openshiftConfigPath != "" {
+		data, err := os.ReadFile(openshiftConfigPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading file: %s", openshiftConfigPath)
+		}
+		document, err := schema.Parse(openshiftConfigPath, data)
+		if err == nil {
+			err = document.Decode(&openshiftConfig)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Error reading exitCodeMapping from %s", openshiftConfigPath)
+		}
+	}
+	return ResolveExitCodeMapping(ExitCodeMapping{}, openshiftConfig.Web.ExitCodeMapping, os.LookupEnv)
+}
+
+// ParseExitCodeMapping : parses a comma separated list of rewrites, like "143=0,3=0"
+func ParseExitCodeMapping(value string) (ExitCodeMapping, error) {
+	mapping := make(ExitCodeMapping)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		from, to, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, errors.Errorf("Exit code mapping %s is not on the form FROM=TO", entry)
+		}
+		fromCode, err := parseExitCode(from)
+		if err != nil {
+			return nil, err
+		}
+		toCode, err := parseExitCode(to)
+		if err != nil {
+			return nil, err
+		}
+		mapping[fromCode] = toCode
+	}
+	return mapping, nil
+}
+
+func parseExitCode(value string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || code < 0 || code > 255 {
+		return 0, errors.Errorf("Exit code %s is not a number between 0 and 255", strings.TrimSpace(value))
+	}
+	return code, nil
+}
+
+// Merge : a new mapping where the rewrites in other take precedence
+func (m ExitCodeMapping) Merge(other ExitCodeMapping) ExitCodeMapping {
+	merged := make(ExitCodeMapping, len(m)+len(other))
+	for from, to := range m {
+		merged[from] = to
+	}
+	for from, to := range other {
+		merged[from] = to
+	}
+	return merged
+}
+
+// Rewrite : the mapped exit code, or the exit code unchanged when it is not mapped
+func (m ExitCodeMapping) Rewrite(exitCode int) int {
+	rewritten, exists := m[exitCode]
+	if !exists || rewritten == exitCode {
+		return exitCode
+	}
+	logrus.Infof("Rewriting exit code %d to %d", exitCode, rewritten)
+	return rewritten
+}
+
+// String : the mapping on the form parsed by ParseExitCodeMapping
+func (m ExitCodeMapping) String() string {
+	codes := make([]int, 0, len(m))
+	for code := range m {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	entries := make([]string, 0, len(codes))
+	for _, code := range codes {
+		entries = append(entries, strconv.Itoa(code)+"="+strconv.Itoa(m[code]))
+	}
+	return strings.Join(entries, ",")
+}
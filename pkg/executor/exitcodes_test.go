@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExitCodeMapping(t *testing.T) {
+	mapping, err := ParseExitCodeMapping("143=0, 3=0,")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExitCodeMapping{143: 0, 3: 0}, mapping)
+	assert.Equal(t, "3=0,143=0", mapping.String())
+}
+
+func TestParseInvalidExitCodeMapping(t *testing.T) {
+	_, err := ParseExitCodeMapping("143")
+	assert.EqualError(t, err, "Exit code mapping 143 is not on the form FROM=TO")
+
+	_, err = ParseExitCodeMapping("143=zero")
+	assert.EqualError(t, err, "Exit code zero is not a number between 0 and 255")
+
+	_, err = ParseExitCodeMapping("256=0")
+	assert.EqualError(t, err, "Exit code 256 is not a number between 0 and 255")
+}
+
+func TestRewriteExitCode(t *testing.T) {
+	mapping := ExitCodeMapping{143: 0}
+
+	assert.Equal(t, 0, mapping.Rewrite(143))
+	assert.Equal(t, 1, mapping.Rewrite(1))
+	assert.Equal(t, 3, ExitCodeMapping{}.Rewrite(3))
+}
+
+func TestResolveExitCodeMapping(t *testing.T) {
+	env := func(key string) (string, bool) {
+		if key == "RADISH_EXIT_CODE_MAPPING" {
+			return "3=1", true
+		}
+		return "", false
+	}
+	defaults := ExitCodeMapping{130: 0, 143: 0}
+
+	mapping, err := ResolveExitCodeMapping(defaults, map[string]int{"143": 143, "3": 0, "4": 0}, env)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExitCodeMapping{130: 0, 143: 143, 3: 1, 4: 0}, mapping)
+	assert.Equal(t, 143, mapping.Rewrite(143))
+	assert.Equal(t, ExitCodeMapping{130: 0, 143: 0}, defaults)
+}
+
+func TestResolveInvalidExitCodeMapping(t *testing.T) {
+	noEnv := func(key string) (string, bool) {
+		return "", false
+	}
+	_, err := ResolveExitCodeMapping(ExitCodeMapping{}, map[string]int{"SIGTERM": 0}, noEnv)
+	assert.EqualError(t, err, "Invalid exitCodeMapping in descriptor: Exit code SIGTERM is not a number between 0 and 255")
+
+	invalidEnv := func(key string) (string, bool) {
+		return "143", true
+	}
+	_, err = ResolveExitCodeMapping(ExitCodeMapping{}, nil, invalidEnv)
+	assert.EqualError(t, err, "Invalid RADISH_EXIT_CODE_MAPPING: Exit code mapping 143 is not on the form FROM=TO")
+}
+
+func TestLoadExitCodeMapping(t *testing.T) {
+	t.Setenv("RADISH_EXIT_CODE_MAPPING", "3=0")
+	openshiftConfig := filepath.Join(t.TempDir(), "radish.yaml")
+	assert.NoError(t, os.WriteFile(openshiftConfig, []byte(`web:
+  exitCodeMapping:
+    "143": 0
+  webapp:
+    content: build
+`), 0644))
+
+	mapping, err := LoadExitCodeMapping(openshiftConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExitCodeMapping{143: 0, 3: 0}, mapping)
+
+	mapping, err = LoadExitCodeMapping("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, ExitCodeMapping{3: 0}, mapping)
+}
@@ -1,14 +1,15 @@
 package java
 
 import (
-	"encoding/json"
 	"github.com/kballard/go-shellquote"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/skatteetaten/radish/pkg/schema"
 	"github.com/skatteetaten/radish/pkg/util"
 
 	"io/ioutil"
@@ -18,37 +19,140 @@ import (
 )
 
 type descriptorData struct {
-	Basedir               string
+	// Basedir is spelled BaseDir in descriptors, which matters for the JSON Schema
+	Basedir               string `json:"BaseDir"`
 	PathsToClassLibraries []string
 	MainClass             string
 	ApplicationArgs       string
 	JavaOptions           string
 	StartScript           string
+	// ExitCodeMapping rewrites exit codes, e.g. {"143": 0}
+	ExitCodeMapping map[string]int
+	// Jvm declares the JVM features that are otherwise configured with environment variables
+	Jvm jvmOptions
+}
+
+// jvmOptions : defaults baked into the image. Each field has the same meaning as the environment variable it
+// replaces, and the environment variable wins when both are set. Unset fields fall back to the radish defaults.
+type jvmOptions struct {
+	EnableJolokia              *bool
+	JolokiaPath                string
+	EnableAppDynamics          *bool
+	AppDynamicsAgentBaseDir    string
+	EnableOtelTrace            *bool
+	OpentelemetryAgentBaseDir  string
+	EnableRemoteDebug          *bool
+	DebugPort                  *int
+	EnableDiagnostics          *bool
+	EnableExitOnOom            *bool
+	EnableGenerationalZgc      *bool
+	MaxRamPercentage           *float64
+	MaxMemRatio                *int
+	MaxMetaspaceRatio          *int
+	HeapDumpPath               string
+	HeapDumpOnOutOfMemoryError *bool
+}
+
+// environment : the environment variables the options correspond to
+func (j jvmOptions) environment() map[string]string {
+	env := make(map[string]string)
+	setString := func(key string, value string) {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	setBool := func(key string, value *bool) {
+		if value != nil {
+			env[key] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(key string, value *int) {
+		if value != nil {
+			env[key] = strconv.Itoa(*value)
+		}
+	}
+	setBool("ENABLE_JOLOKIA", j.EnableJolokia)
+	setString("JOLOKIA_PATH", j.JolokiaPath)
+	setBool("ENABLE_APPDYNAMICS", j.EnableAppDynamics)
+	setString("APPDYNAMICS_AGENT_BASE_DIR", j.AppDynamicsAgentBaseDir)
+	setBool("ENABLE_OTEL_TRACE", j.EnableOtelTrace)
+	setString("OPENTELEMETRY_AGENT_BASE_DIR", j.OpentelemetryAgentBaseDir)
+	setBool("ENABLE_REMOTE_DEBUG", j.EnableRemoteDebug)
+	setInt("DEBUG_PORT", j.DebugPort)
+	setBool("ENABLE_JAVA_DIAGNOSTICS", j.EnableDiagnostics)
+	// ENABLE_EXIT_ON_OOM is enabled by any value, so false is the same as not set
+	if j.EnableExitOnOom != nil && *j.EnableExitOnOom {
+		env["ENABLE_EXIT_ON_OOM"] = "true"
+	}
+	setBool("ENABLE_GENERATIONAL_ZGC", j.EnableGenerationalZgc)
+	if j.MaxRamPercentage != nil {
+		env["JAVA_MAX_RAM_PERCENTAGE"] = strconv.FormatFloat(*j.MaxRamPercentage, 'f', -1, 64)
+	}
+	setInt("JAVA_MAX_MEM_RATIO", j.MaxMemRatio)
+	setInt("JAVA_MAX_METASPACE_RATIO", j.MaxMetaspaceRatio)
+	setString("JAVA_HEAP_DUMP_PATH", j.HeapDumpPath)
+	setBool("JAVA_HEAP_DUMP_ON_OUT_OF_MEMORY_ERROR", j.HeapDumpOnOutOfMemoryError)
+	return env
+}
+
+// withDescriptorDefaults : looks up in env first, and then in the jvm section of the descriptor
+func withDescriptorDefaults(desc descriptor, env func(string) (string, bool)) func(string) (string, bool) {
+	defaults := desc.Data.Jvm.environment()
+	return func(key string) (string, bool) {
+		if value, exists := env(key); exists {
+			return value, exists
+		}
+		value, exists := defaults[key]
+		return value, exists
+	}
 }
 
 type descriptor struct {
-	Type string
-	Data descriptorData
+	Type    string
+	Version string
+	Data    descriptorData
 }
 
 func buildArgline(desc descriptor, env func(string) (string, bool),
 	argumentModificators []ArgumentModificator, cgl util.CGroupLimits) ([]string, error) {
+	args, _, _, err := traceArgline(desc, env, argumentModificators, cgl)
+	return args, err
+}
+
+// traceArgline : builds the argline like buildArgline. In addition it returns where each argument came from,
+// and what each of the modificators did
+func traceArgline(desc descriptor, env func(string) (string, bool),
+	argumentModificators []ArgumentModificator, cgl util.CGroupLimits) ([]string, []string, []ModificatorStep, error) {
 	args := make([]string, 0, 10)
 	classpath, err := createClasspath(desc.Data.Basedir, desc.Data.PathsToClassLibraries)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	} else if len(classpath) == 0 {
 		logrus.Warn("No classpath found... Probably a configuration issue?")
 	} else {
 		args = append(args, "-cp", strings.Join(classpath, ":"))
 	}
-	args = applyArguments(argumentModificators, ArgumentsContext{
+	origins := make(map[string]string)
+	for _, arg := range args {
+		origins[arg] = "PathsToClassLibraries"
+	}
+	args, steps := traceArguments(argumentModificators, ArgumentsContext{
 		Arguments:    args,
-		Environment:  env,
+		Environment:  withDescriptorDefaults(desc, env),
 		CGroupLimits: cgl,
 		Descriptor:   desc,
 	})
+	for _, step := range steps {
+		for _, added := range step.Added {
+			origins[added] = step.Modificator
+		}
+	}
+	sources := make([]string, 0, len(args))
+	for _, arg := range args {
+		sources = append(sources, origins[arg])
+	}
 	args = append(args, desc.Data.MainClass)
+	sources = append(sources, "MainClass")
 	if len(strings.TrimSpace(desc.Data.ApplicationArgs)) != 0 {
 		splittedArgs, err := shellquote.Split(desc.Data.ApplicationArgs)
 		if err == nil {
@@ -56,10 +160,14 @@ func buildArgline(desc descriptor, env func(string) (string, bool),
 		} else {
 			logrus.Warnf("Error parsing args: %s", err)
 			args = append(args, desc.Data.ApplicationArgs)
+			splittedArgs = []string{desc.Data.ApplicationArgs}
+		}
+		for range splittedArgs {
+			sources = append(sources, "ApplicationArgs")
 		}
 	}
 
-	return expandArgumentsAgainstEnv(args, env), nil
+	return expandArgumentsAgainstEnv(args, env), sources, steps, nil
 }
 
 func expandArgumentsAgainstEnv(args []string, env func(string) (string, bool)) []string {
@@ -135,8 +243,23 @@ func walkClasspath(path string) ([]string, error) {
 	return jarfiles, err
 }
 
+func readDescriptor(radishDescriptor string) (descriptor, error) {
+	dat, err := os.ReadFile(radishDescriptor)
+	if err != nil {
+		return descriptor{}, err
+	}
+	return decodeDescriptor(radishDescriptor, dat)
+}
+
 func unmarshallDescriptor(buffer io.Reader) (descriptor, error) {
-	var data descriptor
-	err := json.NewDecoder(buffer).Decode(&data)
-	return data, err
+	dat, err := io.ReadAll(buffer)
+	if err != nil {
+		return descriptor{}, err
+	}
+	return decodeDescriptor("", dat)
+}
+
+// DescriptorSchema : the JSON Schema of the radish descriptor
+func DescriptorSchema() map[string]interface{} {
+	return schema.Generate(descriptor{}, "Radish Java descriptor")
 }
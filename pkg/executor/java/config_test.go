@@ -2,6 +2,7 @@ package java
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/skatteetaten/radish/pkg/util"
 	"github.com/stretchr/testify/assert"
 	"os"
@@ -82,3 +83,80 @@ func TestTokenizationOfShellQuotedArgs(t *testing.T) {
 	assert.Equal(t, args[0], "Class")
 	assert.Equal(t, args[1], "arg1")
 }
+
+func TestJvmOptionsFromDescriptor(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	desc, err := unmarshallDescriptor(bytes.NewBuffer(dat))
+	assert.NoError(t, err)
+	limits := util.CGroupLimits{MemoryLimitInBytes: 2 * 1024 * 1024 * 1024}
+
+	args, err := buildArgline(desc, func(string) (string, bool) {
+		return "", false
+	}, Java11ArgumentsModificators, limits)
+
+	assert.NoError(t, err)
+	assert.Contains(t, args, "-javaagent:/opt/jolokia.jar=host=0.0.0.0,port=8778,protocol=https")
+	assert.Contains(t, args, "-XX:MaxRAMPercentage=60.0")
+	assert.Contains(t, args, "-XX:HeapDumpPath=/dumps")
+	assert.NotContains(t, args, "-XX:+ExitOnOutOfMemoryError")
+}
+
+func TestJvmOptionsEnvironmentWinsOverDescriptor(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	desc, err := unmarshallDescriptor(bytes.NewBuffer(dat))
+	assert.NoError(t, err)
+	environment := map[string]string{
+		"ENABLE_JOLOKIA":          "false",
+		"JAVA_MAX_RAM_PERCENTAGE": "80",
+	}
+	limits := util.CGroupLimits{MemoryLimitInBytes: 2 * 1024 * 1024 * 1024}
+
+	args, err := buildArgline(desc, func(key string) (string, bool) {
+		value, exists := environment[key]
+		return value, exists
+	}, Java11ArgumentsModificators, limits)
+
+	assert.NoError(t, err)
+	assert.NotContains(t, args, "-javaagent:/opt/jolokia.jar=host=0.0.0.0,port=8778,protocol=https")
+	assert.Contains(t, args, "-XX:MaxRAMPercentage=80.0")
+	assert.Contains(t, args, "-XX:HeapDumpPath=/dumps")
+}
+
+func TestValidateDescriptor(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testconfig.json")
+	assert.NoError(t, err)
+	assert.Empty(t, ValidateDescriptor("testdata/testconfig.json", dat))
+
+	dat, err = os.ReadFile("testdata/testconfig-typo.json")
+	assert.NoError(t, err)
+	problems := ValidateDescriptor("testdata/testconfig-typo.json", dat)
+	assert.Len(t, problems, 2)
+	assert.Equal(t, `6:5: Data: unknown field "PathToClassLibraries", did you mean "PathsToClassLibraries"?`, problems[0].String())
+	assert.Equal(t, `11:14: Data.ExitCodeMapping.143: expected integer, got string`, problems[1].String())
+}
+
+func TestPublishedDescriptorSchemaIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../../schemas/java-descriptor.schema.json")
+	assert.NoError(t, err)
+	generated, err := json.MarshalIndent(DescriptorSchema(), "", "  ")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(generated), string(published),
+		"Run radish validateDescriptor --print-schema --type java > schemas/java-descriptor.schema.json")
+}
+
+func TestReadDescriptorInYAMLAndTOML(t *testing.T) {
+	for _, file := range []string{"testdata/testconfig.yaml", "testdata/testconfig.toml"} {
+		desc, err := readDescriptor(file)
+		assert.NoError(t, err, file)
+		assert.Equal(t, "JavaDescriptor", desc.Type, file)
+		assert.Equal(t, "foo.bar.Main", desc.Data.MainClass, file)
+		assert.Equal(t, []string{"lib", "lib/lib2/lib4.jar"}, desc.Data.PathsToClassLibraries, file)
+		assert.Equal(t, map[string]int{"143": 0}, desc.Data.ExitCodeMapping, file)
+
+		dat, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		assert.Empty(t, ValidateDescriptor(file, dat), file)
+	}
+}
@@ -0,0 +1,179 @@
+package java
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/skatteetaten/radish/pkg/util"
+)
+
+// ModificatorStep : what an ArgumentModificator changed, and the environment and cgroup values it was triggered by
+type ModificatorStep struct {
+	Modificator string            `json:"modificator"`
+	Added       []string          `json:"added,omitempty"`
+	Removed     []string          `json:"removed,omitempty"`
+	Environment map[string]string `json:"environment,omitempty"`
+	CGroup      map[string]string `json:"cgroup,omitempty"`
+}
+
+// ArgumentExplanation : an argument in the final command line and where it came from
+type ArgumentExplanation struct {
+	Argument string `json:"argument"`
+	Source   string `json:"source"`
+}
+
+// CGroupExplanation :
+type CGroupExplanation struct {
+	CoresEstimated     float64 `json:"coresEstimated"`
+	MaxCoresEstimated  int     `json:"maxCoresEstimated"`
+	MemoryLimitInBytes int     `json:"memoryLimitInBytes"`
+}
+
+// Explanation : how radish would start java for a descriptor, without starting it
+type Explanation struct {
+	Descriptor       string                `json:"descriptor"`
+	StartScript      string                `json:"startScript,omitempty"`
+	JavaMajorVersion int                   `json:"javaMajorVersion,omitempty"`
+	CGroup           CGroupExplanation     `json:"cgroup"`
+	Modificators     []ModificatorStep     `json:"modificators"`
+	Arguments        []ArgumentExplanation `json:"arguments"`
+	CommandLine      []string              `json:"commandLine"`
+}
+
+// ExplainJava : runs the argument modificators for the descriptor the same way runJava does
+func ExplainJava(radishDescriptor string) (*Explanation, error) {
+	desc, err := readDescriptor(radishDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	limits := util.ReadCGroupLimits()
+	explanation := &Explanation{
+		Descriptor: radishDescriptor,
+		CGroup: CGroupExplanation{
+			CoresEstimated:     limits.CoresEstimated,
+			MaxCoresEstimated:  limits.MaxCoresEstimated,
+			MemoryLimitInBytes: limits.MemoryLimitInBytes,
+		},
+		Modificators: []ModificatorStep{},
+		Arguments:    []ArgumentExplanation{},
+	}
+	if desc.Data.StartScript != "" {
+		explanation.StartScript = desc.Data.StartScript
+		explanation.CommandLine = []string{desc.Data.StartScript}
+		return explanation, nil
+	}
+
+	version, err := resolveJavaMajorVersion(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	argumentModificators, err := findArgumentModificators(version)
+	if err != nil {
+		return nil, err
+	}
+	args, sources, steps, err := traceArgline(desc, os.LookupEnv, argumentModificators, limits)
+	if err != nil {
+		return nil, err
+	}
+	explanation.JavaMajorVersion = version
+	explanation.Modificators = steps
+	for i, arg := range args {
+		explanation.Arguments = append(explanation.Arguments, ArgumentExplanation{
+			Argument: arg,
+			Source:   sources[i],
+		})
+	}
+	explanation.CommandLine = append([]string{"java"}, args...)
+	return explanation, nil
+}
+
+// WriteText : writes the explanation in a human readable form
+func (e *Explanation) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Descriptor:\t%s\n", e.Descriptor)
+	if e.StartScript != "" {
+		fmt.Fprintf(tw, "Start script:\t%s\n", e.StartScript)
+	} else {
+		fmt.Fprintf(tw, "Java version:\t%d\n", e.JavaMajorVersion)
+	}
+	fmt.Fprintf(tw, "CGroup cores:\t%.2f (%d)\n", e.CGroup.CoresEstimated, e.CGroup.MaxCoresEstimated)
+	fmt.Fprintf(tw, "CGroup memory:\t%d bytes\n", e.CGroup.MemoryLimitInBytes)
+
+	if len(e.Modificators) > 0 {
+		fmt.Fprintln(tw, "\nModificators:")
+	}
+	for _, step := range e.Modificators {
+		fmt.Fprintf(tw, "  %s\n", step.Modificator)
+		for _, key := range sortedKeys(step.Environment) {
+			fmt.Fprintf(tw, "    env\t%s=%s\n", key, step.Environment[key])
+		}
+		for _, key := range sortedKeys(step.CGroup) {
+			fmt.Fprintf(tw, "    cgroup\t%s=%s\n", key, step.CGroup[key])
+		}
+		for _, arg := range step.Added {
+			fmt.Fprintf(tw, "    added\t%s\n", arg)
+		}
+		for _, arg := range step.Removed {
+			fmt.Fprintf(tw, "    removed\t%s\n", arg)
+		}
+	}
+
+	if len(e.Arguments) > 0 {
+		fmt.Fprintln(tw, "\nArguments:")
+	}
+	for _, arg := range e.Arguments {
+		fmt.Fprintf(tw, "  %s\t%s\n", arg.Argument, arg.Source)
+	}
+
+	fmt.Fprintf(tw, "\nCommand line:\n  %s\n", shellquote.Join(e.CommandLine...))
+	return tw.Flush()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// recordLookups : wraps the environment, and records the variables that are set when they are looked up
+func recordLookups(env func(string) (string, bool), lookups map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, exists := env(key)
+		if exists {
+			lookups[key] = value
+		}
+		return value, exists
+	}
+}
+
+func modificatorName(mod ArgumentModificator) string {
+	t := reflect.TypeOf(mod)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// difference : the arguments in a that are not in b
+func difference(a []string, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, arg := range b {
+		inB[arg] = true
+	}
+	ret := make([]string, 0)
+	for _, arg := range a {
+		if !inB[arg] {
+			inB[arg] = true
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
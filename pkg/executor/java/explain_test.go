@@ -0,0 +1,99 @@
+package java
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceArgumentsRecordsTriggers(t *testing.T) {
+	env := make(map[string]string)
+	env["JAVA_OPTIONS"] = "-Xtulleball"
+	env["ENABLE_JOLOKIA"] = "true"
+	ctx := createTestContext(env)
+
+	args, steps := traceArguments(Java11ArgumentsModificators, ctx)
+
+	assert.Equal(t, applyArguments(Java11ArgumentsModificators, ctx), args)
+
+	environmentOverride := findStep(steps, "environmentJavaOptionsOverride")
+	if assert.NotNil(t, environmentOverride) {
+		assert.Equal(t, []string{"-Xtulleball"}, environmentOverride.Added)
+		assert.Equal(t, "-Xtulleball", environmentOverride.Environment["JAVA_OPTIONS"])
+	}
+
+	jolokia := findStep(steps, "jolokiaOptions")
+	if assert.NotNil(t, jolokia) {
+		assert.Equal(t, "true", jolokia.Environment["ENABLE_JOLOKIA"])
+		assert.Equal(t, "jolokia.jar", jolokia.Environment["JOLOKIA_PATH"])
+	}
+
+	memory := findStep(steps, "java11PlusMemoryOptions")
+	if assert.NotNil(t, memory) {
+		assert.Equal(t, []string{"-XX:MaxRAMPercentage=75.0"}, memory.Added)
+		assert.Equal(t, "8589934592", memory.CGroup["memoryLimitInBytes"])
+	}
+
+	assert.Nil(t, findStep(steps, "debugOptions"))
+}
+
+func TestTraceArgumentsRecordsRemovedArguments(t *testing.T) {
+	env := make(map[string]string)
+	env["JAVA_OPTIONS"] = "-XX:ConcGCThreads=64"
+	ctx := createTestContext(env)
+	ctx.Descriptor.Data.JavaOptions = ""
+
+	_, steps := traceArguments([]ArgumentModificator{&environmentJavaOptionsOverride{}, &cpuCoreTuning{}}, ctx)
+
+	assert.Nil(t, findStep(steps, "cpuCoreTuning"))
+
+	ctx.Arguments = []string{"-XX:ConcGCThreads=64"}
+	m := &cpuCoreTuning{}
+	args := m.modifyArguments(ctx)
+	assert.Equal(t, []string{"-XX:ConcGCThreads=64"}, difference(ctx.Arguments, args))
+}
+
+func TestExplainJava(t *testing.T) {
+	t.Setenv("JAVA_VERSION_MAJOR", "17")
+	t.Setenv("JAVA_OPTIONS", "-Dfrom.env=true")
+
+	explanation, err := ExplainJava("testdata/testconfig-subpath.json")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 17, explanation.JavaMajorVersion)
+	assert.Equal(t, "java", explanation.CommandLine[0])
+	assert.Contains(t, explanation.CommandLine, "-cp")
+	assert.Equal(t, []string{"foo.bar.Main", "--logging.config=logback.xml"}, explanation.CommandLine[len(explanation.CommandLine)-2:])
+	assert.Contains(t, explanation.Arguments, ArgumentExplanation{Argument: "-cp", Source: "PathsToClassLibraries"})
+	assert.Contains(t, explanation.Arguments, ArgumentExplanation{Argument: "-Dfrom.env=true", Source: "environmentJavaOptionsOverride"})
+	assert.Contains(t, explanation.Arguments, ArgumentExplanation{Argument: "-Dfoo=bar", Source: "descriptorJavaOptionsOverride"})
+	assert.Contains(t, explanation.Arguments, ArgumentExplanation{Argument: "foo.bar.Main", Source: "MainClass"})
+
+	var text bytes.Buffer
+	assert.NoError(t, explanation.WriteText(&text))
+	assert.Regexp(t, `Java version:\s+17`, text.String())
+	assert.Contains(t, text.String(), "JAVA_OPTIONS=-Dfrom.env=true")
+	assert.Contains(t, text.String(), "foo.bar.Main --logging.config=logback.xml")
+
+	dat, err := json.Marshal(explanation)
+	assert.NoError(t, err)
+	assert.Contains(t, string(dat), `"modificator":"environmentJavaOptionsOverride"`)
+}
+
+func TestExplainJavaWithStartScript(t *testing.T) {
+	explanation, err := ExplainJava("testdata/testconfig.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"start.sh"}, explanation.CommandLine)
+	assert.Empty(t, explanation.Modificators)
+}
+
+func findStep(steps []ModificatorStep, modificator string) *ModificatorStep {
+	for i := range steps {
+		if steps[i].Modificator == modificator {
+			return &steps[i]
+		}
+	}
+	return nil
+}
@@ -1,18 +1,33 @@
 package java
 
 import (
-	"bytes"
+	"bufio"
 	"fmt"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/crash"
 	"github.com/skatteetaten/radish/pkg/executor"
 	"github.com/skatteetaten/radish/pkg/util"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
 )
 
+// defaultExitCodeMapping : the JVM exits with 128 + the signal when it is stopped by SIGINT or SIGTERM
+var defaultExitCodeMapping = executor.ExitCodeMapping{
+	int(syscall.SIGINT) + 128:  0,
+	int(syscall.SIGTERM) + 128: 0,
+}
+
 type javaExitHandler struct {
+	exitCodeMapping executor.ExitCodeMapping
+	crashCollector  *crash.Collector
+	// exitOnOutOfMemoryError : the JVM exits with exitOnOutOfMemoryErrorCode on OutOfMemoryError, so that code is a crash
+	exitOnOutOfMemoryError bool
 }
 
 type generatedJavaExecutor struct {
@@ -22,21 +37,26 @@ type generatedJavaExecutor struct {
 // NewJavaExecutor :
 func NewJavaExecutor() executor.Executor {
 	return &generatedJavaExecutor{
-		javaExitHandler: javaExitHandler{},
+		javaExitHandler: javaExitHandler{
+			exitCodeMapping: defaultExitCodeMapping,
+			crashCollector:  crash.NewCollector([]string{}, os.LookupEnv),
+		},
 	}
 }
 
 func (m *generatedJavaExecutor) BuildCmd(radishDescriptor string) (*exec.Cmd, error) {
-	dat, err := os.ReadFile(radishDescriptor)
+	desc, err := readDescriptor(radishDescriptor)
 	if err != nil {
 		return nil, err
 	}
-	desc, err := unmarshallDescriptor(bytes.NewBuffer(dat))
+	m.exitCodeMapping, err = executor.ResolveExitCodeMapping(defaultExitCodeMapping, desc.Data.ExitCodeMapping, os.LookupEnv)
 	if err != nil {
 		return nil, err
 	}
 	// Use provided start script
 	if desc.Data.StartScript != "" {
+		enableExitOnOom, _ := os.LookupEnv("ENABLE_EXIT_ON_OOM")
+		m.exitOnOutOfMemoryError = enableExitOnOom != ""
 		cmd := exec.Command(desc.Data.StartScript)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
@@ -49,6 +69,9 @@ func (m *generatedJavaExecutor) BuildCmd(radishDescriptor string) (*exec.Cmd, er
 	if err != nil {
 		return nil, err
 	}
+	m.crashCollector = crash.NewCollector(args, os.LookupEnv)
+	m.exitOnOutOfMemoryError = containsArgument(args, "-XX:+ExitOnOutOfMemoryError")
+	warnWithoutContainerSupport(args)
 	cmd := exec.Command("java", args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -58,11 +81,7 @@ func (m *generatedJavaExecutor) BuildCmd(radishDescriptor string) (*exec.Cmd, er
 }
 
 func (m *generatedJavaExecutor) BuildClasspath(radishDescriptor string) (string, error) {
-	dat, err := os.ReadFile(radishDescriptor)
-	if err != nil {
-		return "", err
-	}
-	desc, err := unmarshallDescriptor(bytes.NewBuffer(dat))
+	desc, err := readDescriptor(radishDescriptor)
 	if err != nil {
 		return "", err
 	}
@@ -73,47 +92,155 @@ func (m *generatedJavaExecutor) BuildClasspath(radishDescriptor string) (string,
 	return strings.Join(jars, ":"), nil
 }
 
+// warnWithoutContainerSupport : Java reads the cgroup limits by default. Without container support MaxRAMPercentage
+// and the active processor count are taken from the host.
+func warnWithoutContainerSupport(args []string) {
+	if containsArgument(args, "-XX:-UseContainerSupport") {
+		logrus.Warn("Container support is turned off with -XX:-UseContainerSupport. Memory and CPU defaults are based on the host, not the container limits")
+	}
+}
+
+type javaVersionModificators struct {
+	minimumVersion int
+	modificators   []ArgumentModificator
+}
+
+// javaArgumentModificatorRegistry : Sorted by minimumVersion. A Java version uses the entry with the highest
+// minimumVersion not above it, so 11 covers 11-16 and 24 covers 24 and every newer version.
+var javaArgumentModificatorRegistry = []javaVersionModificators{
+	{minimumVersion: 8, modificators: Java8ArgumentsModificators},
+	{minimumVersion: 11, modificators: Java11ArgumentsModificators},
+	{minimumVersion: 17, modificators: Java17ArgumentsModificators},
+	{minimumVersion: 21, modificators: Java21ArgumentsModificators},
+	{minimumVersion: 24, modificators: Java24ArgumentsModificators},
+}
+
 func resolveArgumentModificators(env func(string) string) []ArgumentModificator {
-	// This is set by the base image (wingnut<X>):
-	majorVersion := env("JAVA_VERSION_MAJOR")
-	switch majorVersion {
-	case "8":
-		logrus.Debug("Starting Java 8 process")
-		return Java8ArgumentsModificators
-	case "11":
-		logrus.Debug("Starting Java 11 process")
-		return Java11ArgumentsModificators
-	case "17":
-		logrus.Debug("Starting Java 17 process")
-		return Java17ArgumentsModificators
-	default:
-		panic(fmt.Sprintf("Unsupported JAVA_VERSION_MAJOR: %s", majorVersion))
+	version, err := resolveJavaMajorVersion(env)
+	if err != nil {
+		panic(err.Error())
+	}
+	modificators, err := findArgumentModificators(version)
+	if err != nil {
+		panic(err.Error())
+	}
+	return modificators
+}
+
+// javaVersionOutput : the output of java -version, which is printed to stderr
+var javaVersionOutput = func() ([]byte, error) {
+	return exec.Command("java", "-version").CombinedOutput()
+}
+
+var javaVersionPattern = regexp.MustCompile(`version "([^"]+)"`)
+
+// resolveJavaMajorVersion : JAVA_VERSION_MAJOR is set by the base image (wingnut<X>), and overrides detection.
+// Other images get the version from $JAVA_HOME/release, or from java -version as a last resort.
+func resolveJavaMajorVersion(env func(string) string) (int, error) {
+	if majorVersion := env("JAVA_VERSION_MAJOR"); majorVersion != "" {
+		version, err := parseJavaMajorVersion(majorVersion)
+		if err != nil {
+			return 0, fmt.Errorf("Unsupported JAVA_VERSION_MAJOR: %s", majorVersion)
+		}
+		return version, nil
+	}
+
+	if javaHome := env("JAVA_HOME"); javaHome != "" {
+		version, err := readJavaReleaseFile(filepath.Join(javaHome, "release"))
+		if err == nil {
+			logrus.Debugf("Found Java %d in %s", version, javaHome)
+			return version, nil
+		}
+		logrus.Debugf("Could not detect Java version from JAVA_HOME: %s", err)
+	}
+
+	output, err := javaVersionOutput()
+	if err != nil {
+		return 0, errors.Wrap(err, "JAVA_VERSION_MAJOR is not set, and java -version failed")
+	}
+	match := javaVersionPattern.FindSubmatch(output)
+	if match == nil {
+		return 0, fmt.Errorf("JAVA_VERSION_MAJOR is not set, and no version found in the output of java -version: %s", output)
+	}
+	version, err := parseJavaMajorVersion(string(match[1]))
+	if err != nil {
+		return 0, err
 	}
+	logrus.Debugf("Found Java %d with java -version", version)
+	return version, nil
 }
 
+// readJavaReleaseFile : the release file in the JDK contains a line like JAVA_VERSION="17.0.2"
+func readJavaReleaseFile(releaseFile string) (int, error) {
+	file, err := os.Open(releaseFile)
+	if err != nil {
+		return 0, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "JAVA_VERSION=") {
+			return parseJavaMajorVersion(strings.TrimPrefix(line, "JAVA_VERSION="))
+		}
+	}
+	return 0, fmt.Errorf("No JAVA_VERSION in %s", releaseFile)
+}
+
+// parseJavaMajorVersion : handles both the old scheme (1.8.0_292) and the new one (17.0.2, 21-ea, 21)
+func parseJavaMajorVersion(version string) (int, error) {
+	parts := strings.FieldsFunc(strings.Trim(strings.TrimSpace(version), `"`), func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || r == '+'
+	})
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("Could not parse Java version %s", version)
+	}
+	if parts[0] == "1" && len(parts) > 1 {
+		parts = parts[1:]
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("Could not parse Java version %s", version)
+	}
+	return major, nil
+}
+
+func findArgumentModificators(version int) ([]ArgumentModificator, error) {
+	for i := len(javaArgumentModificatorRegistry) - 1; i >= 0; i-- {
+		entry := javaArgumentModificatorRegistry[i]
+		if version < entry.minimumVersion {
+			continue
+		}
+		if i == len(javaArgumentModificatorRegistry)-1 && version > entry.minimumVersion {
+			logrus.Infof("Java %d is newer than the latest known version. Using arguments for Java %d+", version, entry.minimumVersion)
+		} else {
+			logrus.Debugf("Starting Java %d process with arguments for Java %d+", version, entry.minimumVersion)
+		}
+		return entry.modificators, nil
+	}
+	return nil, fmt.Errorf("Unsupported JAVA_VERSION_MAJOR: %d", version)
+}
+
+// exitOnOutOfMemoryErrorCode : the exit code of the JVM when -XX:+ExitOnOutOfMemoryError (ENABLE_EXIT_ON_OOM) is set
+const exitOnOutOfMemoryErrorCode = 3
+
 func (m *javaExitHandler) HandleExit(exitCode int, pid int) int {
 	if exitCode == int(syscall.SIGABRT)+128 {
 		logrus.Info("Java is out of memory! Bummer")
-		printCoreFileToStdOut(pid)
-		return exitCode
+		m.crashCollector.Collect(pid)
+	}
+	if exitCode == exitOnOutOfMemoryErrorCode && m.exitOnOutOfMemoryError {
+		logrus.Info("Java exited on OutOfMemoryError")
+		m.crashCollector.Collect(pid)
 	}
 	if exitCode == int(syscall.SIGINT)+128 {
-		logrus.Info("Java terminated successfully from a SIGINT")
-		return 0
+		logrus.Info("Java terminated from a SIGINT")
 	}
 	if exitCode == int(syscall.SIGTERM)+128 {
-		logrus.Info("Java terminated successfully from a SIGTERM")
-		return 0
-	}
-	return exitCode
-}
-
-func printCoreFileToStdOut(pid int) {
-	report := fmt.Sprintf("hs_err_pid%d.log", pid)
-	crashReport, err := os.ReadFile(report)
-	if err != nil {
-		logrus.Errorf("Error reading crash report %s", report)
-		return
+		logrus.Info("Java terminated from a SIGTERM")
 	}
-	logrus.Info(string(crashReport))
+	return m.exitCodeMapping.Rewrite(exitCode)
 }
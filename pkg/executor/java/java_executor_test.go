@@ -1,9 +1,17 @@
 package java
 
 import (
-	"github.com/stretchr/testify/assert"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/skatteetaten/radish/pkg/crash"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHandleExit(t *testing.T) {
@@ -29,6 +37,75 @@ func TestHandleExit(t *testing.T) {
 
 }
 
+func TestHandleExitCollectsHeapDumpOnExitOnOutOfMemoryError(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "java_pid7.hprof"), []byte("heap"), 0644))
+	var reports []crash.Report
+	collector := crash.NewCollector([]string{"-XX:HeapDumpPath=" + dir}, func(string) (string, bool) { return "", false })
+	collector.AddHook(func(report crash.Report) error {
+		reports = append(reports, report)
+		return nil
+	})
+	handler := javaExitHandler{exitCodeMapping: defaultExitCodeMapping, crashCollector: collector}
+
+	assert.Equal(t, 3, handler.HandleExit(exitOnOutOfMemoryErrorCode, 7))
+	assert.Empty(t, reports, "3 is an ordinary exit code without -XX:+ExitOnOutOfMemoryError")
+
+	handler.exitOnOutOfMemoryError = true
+	assert.Equal(t, 0, handler.HandleExit(int(syscall.SIGTERM)+128, 7))
+	assert.Empty(t, reports)
+
+	assert.Equal(t, 3, handler.HandleExit(exitOnOutOfMemoryErrorCode, 7))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, filepath.Join(dir, "java_pid7.hprof"), reports[0].HeapDump)
+}
+
+func TestBuildCmdRecordsExitOnOutOfMemoryError(t *testing.T) {
+	t.Setenv("JAVA_VERSION_MAJOR", "17")
+	executor := &generatedJavaExecutor{}
+
+	_, err := executor.BuildCmd("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	assert.False(t, executor.exitOnOutOfMemoryError)
+
+	t.Setenv("ENABLE_EXIT_ON_OOM", "true")
+	cmd, err := executor.BuildCmd("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	assert.Contains(t, cmd.Args, "-XX:+ExitOnOutOfMemoryError")
+	assert.True(t, executor.exitOnOutOfMemoryError)
+
+	// The start script gets ENABLE_EXIT_ON_OOM in its environment
+	_, err = executor.BuildCmd("testdata/testconfig.json")
+	assert.NoError(t, err)
+	assert.True(t, executor.exitOnOutOfMemoryError)
+}
+
+func TestBuildCmdWarnsWithoutContainerSupport(t *testing.T) {
+	t.Setenv("JAVA_VERSION_MAJOR", "21")
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	warnings := func() []string {
+		var messages []string
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "UseContainerSupport") {
+				messages = append(messages, entry.Message)
+			}
+		}
+		return messages
+	}
+
+	_, err := NewJavaExecutor().BuildCmd("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	assert.Empty(t, warnings())
+
+	t.Setenv("JAVA_OPTIONS", "-XX:-UseContainerSupport")
+	cmd, err := NewJavaExecutor().BuildCmd("testdata/testconfig-jvm.json")
+	assert.NoError(t, err)
+	assert.Contains(t, cmd.Args, "-XX:-UseContainerSupport")
+	assert.Equal(t, []string{"Container support is turned off with -XX:-UseContainerSupport. " +
+		"Memory and CPU defaults are based on the host, not the container limits"}, warnings())
+}
+
 func TestBuildClasspath(t *testing.T) {
 	executor := NewJavaExecutor()
 	descriptor := "testdata/testconfig.json"
@@ -78,8 +155,142 @@ func TestJava17ArgumentModificators(t *testing.T) {
 	assert.Equal(t, Java17ArgumentsModificators, argumentModificators)
 }
 
+func TestJava21ArgumentModificators(t *testing.T) {
+	argumentModificators := resolveArgumentModificators(javaVersionLookupFor("21"))
+	assert.Equal(t, Java21ArgumentsModificators, argumentModificators)
+}
+
+func TestJava24ArgumentModificators(t *testing.T) {
+	argumentModificators := resolveArgumentModificators(javaVersionLookupFor("24"))
+	assert.Equal(t, Java24ArgumentsModificators, argumentModificators)
+}
+
+func TestVersionsBetweenKnownVersionsUseClosestLowerVersion(t *testing.T) {
+	argumentModificators := resolveArgumentModificators(javaVersionLookupFor("15"))
+	assert.Equal(t, Java11ArgumentsModificators, argumentModificators)
+
+	argumentModificators = resolveArgumentModificators(javaVersionLookupFor("23"))
+	assert.Equal(t, Java21ArgumentsModificators, argumentModificators)
+}
+
+func TestUnknownNewerJavaVersionUsesLatestKnownVersion(t *testing.T) {
+	argumentModificators := resolveArgumentModificators(javaVersionLookupFor("26"))
+	assert.Equal(t, Java24ArgumentsModificators, argumentModificators)
+}
+
+func TestThatTooOldJavaVersionCausesPanic(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("The code did not panic")
+		}
+	}()
+
+	resolveArgumentModificators(javaVersionLookupFor("7"))
+}
+
+func TestDetectJavaVersionFromReleaseFile(t *testing.T) {
+	version, err := resolveJavaMajorVersion(envLookupFor(map[string]string{"JAVA_HOME": "testdata/jdk17"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 17, version)
+
+	version, err = resolveJavaMajorVersion(envLookupFor(map[string]string{"JAVA_HOME": "testdata/jdk8"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 8, version)
+}
+
+func TestJavaVersionMajorOverridesDetection(t *testing.T) {
+	version, err := resolveJavaMajorVersion(envLookupFor(map[string]string{
+		"JAVA_HOME":          "testdata/jdk17",
+		"JAVA_VERSION_MAJOR": "11",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, 11, version)
+}
+
+func TestDetectJavaVersionFromJavaBinary(t *testing.T) {
+	defer func(original func() ([]byte, error)) {
+		javaVersionOutput = original
+	}(javaVersionOutput)
+
+	javaVersionOutput = func() ([]byte, error) {
+		return []byte(`openjdk version "21.0.1" 2023-10-17 LTS
+OpenJDK Runtime Environment Temurin-21.0.1+12 (build 21.0.1+12-LTS)
+OpenJDK 64-Bit Server VM Temurin-21.0.1+12 (build 21.0.1+12-LTS, mixed mode, sharing)`), nil
+	}
+	version, err := resolveJavaMajorVersion(envLookupFor(map[string]string{"JAVA_HOME": "testdata/nonexisting"}))
+	assert.NoError(t, err)
+	assert.Equal(t, 21, version)
+
+	javaVersionOutput = func() ([]byte, error) {
+		return []byte(`java version "1.8.0_292"`), nil
+	}
+	version, err = resolveJavaMajorVersion(envLookupFor(map[string]string{}))
+	assert.NoError(t, err)
+	assert.Equal(t, 8, version)
+
+	javaVersionOutput = func() ([]byte, error) {
+		return nil, errors.New("exec: \"java\": executable file not found in $PATH")
+	}
+	_, err = resolveJavaMajorVersion(envLookupFor(map[string]string{}))
+	assert.Error(t, err)
+}
+
+func TestParseJavaMajorVersion(t *testing.T) {
+	for version, expected := range map[string]int{
+		"1.8.0_292":   8,
+		"\"11.0.20\"": 11,
+		"17":          17,
+		"21-ea":       21,
+		"22+36":       22,
+	} {
+		major, err := parseJavaMajorVersion(version)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, major, version)
+	}
+	_, err := parseJavaMajorVersion("UNKNOWN VERSION")
+	assert.Error(t, err)
+}
+
+func envLookupFor(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
 func javaVersionLookupFor(javaVersion string) func(string) string {
 	return func(s string) string {
 		return javaVersion
 	}
 }
+
+func TestHandleExitWithExitCodeMapping(t *testing.T) {
+	t.Setenv("RADISH_EXIT_CODE_MAPPING", "4=0")
+	executor := NewJavaExecutor()
+	_, err := executor.BuildCmd("testdata/testconfig-exitcodes.json")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 143, executor.HandleExit(int(syscall.SIGTERM)+128, 1))
+	assert.Equal(t, 0, executor.HandleExit(int(syscall.SIGINT)+128, 1))
+	assert.Equal(t, 0, executor.HandleExit(3, 1))
+	assert.Equal(t, 0, executor.HandleExit(4, 1))
+	assert.Equal(t, 5, executor.HandleExit(5, 1))
+}
+
+func TestBuildCmdFailsWithInvalidExitCodeMapping(t *testing.T) {
+	t.Setenv("RADISH_EXIT_CODE_MAPPING", "143")
+	executor := NewJavaExecutor()
+
+	_, err := executor.BuildCmd("testdata/testconfig-exitcodes.json")
+
+	assert.Error(t, err)
+}
+
+func TestBuildCmdFailsOnDescriptorProblems(t *testing.T) {
+	executor := NewJavaExecutor()
+
+	_, err := executor.BuildCmd("testdata/testconfig-typo.json")
+
+	assert.EqualError(t, err, `Radish descriptor has problems: `+
+		`6:5: Data: unknown field "PathToClassLibraries", did you mean "PathsToClassLibraries"?, `+
+		`11:14: Data.ExitCodeMapping.143: expected integer, got string. Set RADISH_STRICT_DESCRIPTOR=false to only log them`)
+}
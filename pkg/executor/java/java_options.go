@@ -25,6 +25,30 @@ type ArgumentModificator interface {
 	modifyArguments(context ArgumentsContext) []string
 }
 
+// cgroupDependent : implemented by modificators that derive arguments from the cgroup limits
+type cgroupDependent interface {
+	cgroupTrigger(limits util.CGroupLimits) map[string]string
+}
+
+func cpuTrigger(limits util.CGroupLimits) map[string]string {
+	if !limits.HasCoreLimit() {
+		return nil
+	}
+	return map[string]string{
+		"coresEstimated":    fmt.Sprintf("%.2f", limits.CoresEstimated),
+		"maxCoresEstimated": strconv.Itoa(limits.MaxCoresEstimated),
+	}
+}
+
+func memoryTrigger(limits util.CGroupLimits) map[string]string {
+	if !limits.HasMemoryLimit() {
+		return nil
+	}
+	return map[string]string{
+		"memoryLimitInBytes": strconv.Itoa(limits.MemoryLimitInBytes),
+	}
+}
+
 // Java8ArgumentsModificators :
 var Java8ArgumentsModificators = []ArgumentModificator{
 	&environmentJavaOptionsOverride{},
@@ -58,6 +82,36 @@ var Java11ArgumentsModificators = []ArgumentModificator{
 // Java17ArgumentsModificators :
 var Java17ArgumentsModificators = Java11ArgumentsModificators
 
+// Java21ArgumentsModificators :
+var Java21ArgumentsModificators = []ArgumentModificator{
+	&environmentJavaOptionsOverride{},
+	&descriptorJavaOptionsOverride{},
+	&enableExitOnOom{},
+	&debugOptions{},
+	&java11PlusDiagnosticsOptions{},
+	&jolokiaOptions{},
+	&appDynamicsOptions{},
+	&otelOptions{},
+	&generationalZgcOptions{arguments: []string{"-XX:+UseZGC", "-XX:+ZGenerational"}},
+	&java11PlusMemoryOptions{},
+	&heapDumpOptions{},
+}
+
+// Java24ArgumentsModificators : ZGC is only generational from Java 24, and -XX:+ZGenerational is obsolete
+var Java24ArgumentsModificators = []ArgumentModificator{
+	&environmentJavaOptionsOverride{},
+	&descriptorJavaOptionsOverride{},
+	&enableExitOnOom{},
+	&debugOptions{},
+	&java11PlusDiagnosticsOptions{},
+	&jolokiaOptions{},
+	&appDynamicsOptions{},
+	&otelOptions{},
+	&generationalZgcOptions{arguments: []string{"-XX:+UseZGC"}},
+	&java11PlusMemoryOptions{},
+	&heapDumpOptions{},
+}
+
 type java11PlusDiagnosticsOptions struct {
 	diagnosticsOptions
 }
@@ -169,10 +223,15 @@ func (m *cpuCoreTuning) shouldModifyArguments(context ArgumentsContext) bool {
 	return !containsArgument(context.Arguments, cpuCoreArguments...) && context.CGroupLimits.HasCoreLimit()
 }
 
+func (m *cpuCoreTuning) cgroupTrigger(limits util.CGroupLimits) map[string]string {
+	return cpuTrigger(limits)
+}
+
 func (m *cpuCoreTuning) modifyArguments(context ArgumentsContext) []string {
-	args := removeArguments(context.Arguments, memoryArguments)
+	args := removeArguments(context.Arguments, cpuCoreArguments)
 	limits := context.CGroupLimits
 	if limits.HasCoreLimit() {
+		logrus.Debugf("Estimated %.2f cores available, using %d", limits.CoresEstimated, limits.MaxCoresEstimated)
 		args = append([]string{fmt.Sprintf("-XX:ParallelGCThreads=%d", limits.MaxCoresEstimated)}, args...)
 		args = append([]string{fmt.Sprintf("-XX:ConcGCThreads=%d", limits.MaxCoresEstimated)}, args...)
 		args = append([]string{fmt.Sprintf("-Djava.util.concurrent.ForkJoinPool.common.parallelism=%d", limits.MaxCoresEstimated)}, args...)
@@ -233,7 +292,7 @@ func (m *otelOptions) modifyArguments(context ArgumentsContext) []string {
 	}
 	// uniqueHostId used to identify POD's by AppD machine agent
 	otelAgentArguments := fmt.Sprintf("-javaagent:%s/opentelemetry-javaagent.jar", opentelemetryBaseDir)
-	args = append([]string{otelAgentArguments})
+	args = []string{otelAgentArguments}
 
 	args = append(args,
 		fmt.Sprintf("-Dotel.resource.attributes=service.name=%s,service.namespace=%s,service.cluster=%s", appName, namespace, cluster),
@@ -316,7 +375,7 @@ func (m *appDynamicsOptions) modifyArguments(context ArgumentsContext) []string
 
 	// uniqueHostId used to identify POD's by AppD machine agent
 	appDynamicsArgument := fmt.Sprintf("-javaagent:%s/javaagent.jar", appDynamicsBaseDir)
-	args = append([]string{appDynamicsArgument})
+	args = []string{appDynamicsArgument}
 	args = append(args, "-Dappdynamics.agent.applicationName="+agentAppName,
 		"-Dappdynamics.agent.tierName="+agentTierName,
 		"-Dappdynamics.agent.nodeName="+agentNodeName,
@@ -347,6 +406,10 @@ func (m *java8MemoryOptions) shouldModifyArguments(context ArgumentsContext) boo
 	return !containsArgument(context.Arguments, memoryArguments...)
 }
 
+func (m *java8MemoryOptions) cgroupTrigger(limits util.CGroupLimits) map[string]string {
+	return memoryTrigger(limits)
+}
+
 func (m *java8MemoryOptions) modifyArguments(context ArgumentsContext) []string {
 	args := removeArguments(context.Arguments, memoryArguments)
 	memRatio, exists := context.Environment("JAVA_MAX_MEM_RATIO")
@@ -376,6 +439,10 @@ func (m *java11PlusMemoryOptions) shouldModifyArguments(context ArgumentsContext
 	return !containsArgument(context.Arguments, memoryArguments...)
 }
 
+func (m *java11PlusMemoryOptions) cgroupTrigger(limits util.CGroupLimits) map[string]string {
+	return memoryTrigger(limits)
+}
+
 func (m *java11PlusMemoryOptions) modifyArguments(context ArgumentsContext) []string {
 	args := removeArguments(context.Arguments, memoryArguments)
 	maxMemory, exists := context.Environment("JAVA_MAX_RAM_PERCENTAGE")
@@ -399,6 +466,33 @@ func (m *java11PlusMemoryOptions) modifyArguments(context ArgumentsContext) []st
 	return args
 }
 
+var garbageCollectorArguments = []string{"-XX:+UseZGC",
+	"-XX:+UseG1GC",
+	"-XX:+UseParallelGC",
+	"-XX:+UseSerialGC",
+	"-XX:+UseShenandoahGC",
+	"-XX:+UseEpsilonGC"}
+
+type generationalZgcOptions struct {
+	arguments []string
+}
+
+func (m *generationalZgcOptions) shouldModifyArguments(context ArgumentsContext) bool {
+	value, exists := context.Environment("ENABLE_GENERATIONAL_ZGC")
+	if !exists || strings.ToUpper(value) != "TRUE" {
+		return false
+	}
+	if containsArgument(context.Arguments, garbageCollectorArguments...) {
+		logrus.Warn("ENABLE_GENERATIONAL_ZGC is set, but a garbage collector is already selected in the java options")
+		return false
+	}
+	return true
+}
+
+func (m *generationalZgcOptions) modifyArguments(context ArgumentsContext) []string {
+	return append(append([]string{}, m.arguments...), context.Arguments...)
+}
+
 var metaspaceArguments = []string{"-XX:MaxMetaspaceSize"}
 
 type metaspaceOptions struct {
@@ -412,6 +506,10 @@ func (m *metaspaceOptions) shouldModifyArguments(context ArgumentsContext) bool
 	return exists
 }
 
+func (m *metaspaceOptions) cgroupTrigger(limits util.CGroupLimits) map[string]string {
+	return memoryTrigger(limits)
+}
+
 func (m *metaspaceOptions) modifyArguments(context ArgumentsContext) []string {
 	args := removeArguments(context.Arguments, metaspaceArguments)
 	memRatio, exists := context.Environment("JAVA_MAX_METASPACE_RATIO")
@@ -456,9 +554,9 @@ func (m *heapDumpOptions) modifyArguments(context ArgumentsContext) []string {
 	if !containsArgument(context.Arguments, heapDumpPathArgs...) {
 		javaHeapDumpPath, exists := context.Environment("JAVA_HEAP_DUMP_PATH")
 		if exists {
-			args = append([]string{fmt.Sprintf("-XX:HeapDumpPath=%s", javaHeapDumpPath)})
+			args = []string{fmt.Sprintf("-XX:HeapDumpPath=%s", javaHeapDumpPath)}
 		} else {
-			args = append([]string{"-XX:HeapDumpPath=/tmp"})
+			args = []string{"-XX:HeapDumpPath=/tmp"}
 		}
 	}
 
@@ -499,13 +597,33 @@ func containsArgument(arguments []string, argument ...string) bool {
 }
 
 func applyArguments(modificators []ArgumentModificator, ctx ArgumentsContext) []string {
+	args, _ := traceArguments(modificators, ctx)
+	return args
+}
+
+// traceArguments : applies the modificators, and records what each of them changed and why
+func traceArguments(modificators []ArgumentModificator, ctx ArgumentsContext) ([]string, []ModificatorStep) {
+	steps := make([]ModificatorStep, 0)
 	for _, mod := range modificators {
-		if mod.shouldModifyArguments(ctx) {
+		lookups := make(map[string]string)
+		traced := ctx
+		traced.Environment = recordLookups(ctx.Environment, lookups)
+		if mod.shouldModifyArguments(traced) {
 			logrus.Debugf("Arguments before modificator %s is %+v", reflect.TypeOf(mod), ctx.Arguments)
-			args := mod.modifyArguments(ctx)
+			args := mod.modifyArguments(traced)
+			step := ModificatorStep{
+				Modificator: modificatorName(mod),
+				Added:       difference(args, ctx.Arguments),
+				Removed:     difference(ctx.Arguments, args),
+				Environment: lookups,
+			}
+			if dependent, ok := mod.(cgroupDependent); ok {
+				step.CGroup = dependent.cgroupTrigger(ctx.CGroupLimits)
+			}
+			steps = append(steps, step)
 			ctx.Arguments = args
 			logrus.Debugf("Arguments after modificator %s is %+v", reflect.TypeOf(mod), ctx.Arguments)
 		}
 	}
-	return ctx.Arguments
+	return ctx.Arguments, steps
 }
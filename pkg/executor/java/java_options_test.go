@@ -68,6 +68,54 @@ func TestJava17DefaultOptions(t *testing.T) {
 	assert.Len(t, modifiedArgs, 5)
 }
 
+func TestJava21DefaultOptions(t *testing.T) {
+	env := make(map[string]string)
+	ctx := createTestContext(env)
+	modifiedArgs := applyArguments(Java21ArgumentsModificators, ctx)
+	assert.Contains(t, modifiedArgs, "-XX:HeapDumpPath=/tmp")
+	assert.Contains(t, modifiedArgs, "-XX:+HeapDumpOnOutOfMemoryError")
+	assert.Contains(t, modifiedArgs, "-XX:MaxRAMPercentage=75.0")
+	assert.NotContains(t, modifiedArgs, "-XX:+UseZGC")
+	assert.Len(t, modifiedArgs, 5)
+}
+
+func TestJava21GenerationalZgc(t *testing.T) {
+	env := make(map[string]string)
+	env["ENABLE_GENERATIONAL_ZGC"] = "true"
+	ctx := createTestContext(env)
+	modifiedArgs := applyArguments(Java21ArgumentsModificators, ctx)
+	assert.Contains(t, modifiedArgs, "-XX:+UseZGC")
+	assert.Contains(t, modifiedArgs, "-XX:+ZGenerational")
+
+	env["JAVA_OPTIONS"] = "-XX:+UseG1GC"
+	ctx = createTestContext(env)
+	modifiedArgs = applyArguments(Java21ArgumentsModificators, ctx)
+	assert.Contains(t, modifiedArgs, "-XX:+UseG1GC")
+	assert.NotContains(t, modifiedArgs, "-XX:+UseZGC")
+	assert.NotContains(t, modifiedArgs, "-XX:+ZGenerational")
+}
+
+func TestJava24GenerationalZgc(t *testing.T) {
+	env := make(map[string]string)
+	env["ENABLE_GENERATIONAL_ZGC"] = "true"
+	ctx := createTestContext(env)
+	modifiedArgs := applyArguments(Java24ArgumentsModificators, ctx)
+	assert.Contains(t, modifiedArgs, "-XX:+UseZGC")
+	assert.NotContains(t, modifiedArgs, "-XX:+ZGenerational")
+}
+
+func TestJava21WithoutContainerSupport(t *testing.T) {
+	env := make(map[string]string)
+	env["JAVA_OPTIONS"] = "-XX:-UseContainerSupport"
+	env["JAVA_MAX_RAM_PERCENTAGE"] = "50"
+	ctx := createTestContext(env)
+	modifiedArgs := applyArguments(Java21ArgumentsModificators, ctx)
+	assert.Contains(t, modifiedArgs, "-XX:-UseContainerSupport")
+	assert.Contains(t, modifiedArgs, "-XX:MaxRAMPercentage=50.0")
+	assert.NotContains(t, modifiedArgs, "-XX:+UseContainerSupport")
+	assert.NotContains(t, modifiedArgs, "-Xmx4096m")
+}
+
 func TestOptionsJolokia(t *testing.T) {
 	env := make(map[string]string)
 	env["ENABLE_JOLOKIA"] = "true"
@@ -260,6 +308,22 @@ func TestJavaMaxMetaspaceMemRatio(t *testing.T) {
 
 }
 
+func TestCpuCoreTuningKeepsCpuArgumentsFromJavaOptions(t *testing.T) {
+	env := make(map[string]string)
+	ctx := createTestContext(env)
+	args := applyArguments(Java8ArgumentsModificators, ctx)
+	assert.Contains(t, args, "-XX:ParallelGCThreads=4")
+	assert.Contains(t, args, "-XX:ConcGCThreads=4")
+
+	env["JAVA_OPTIONS"] = "-XX:ParallelGCThreads=64 -Xmx1024m"
+	ctx = createTestContext(env)
+	args = applyArguments(Java8ArgumentsModificators, ctx)
+	assert.Contains(t, args, "-XX:ParallelGCThreads=64")
+	assert.NotContains(t, args, "-XX:ParallelGCThreads=4")
+	assert.NotContains(t, args, "-XX:ConcGCThreads=4")
+	assert.Contains(t, args, "-Xmx1024m")
+}
+
 func TestExitOnOom(t *testing.T) {
 	env["ENABLE_EXIT_ON_OOM"] = "1"
 	ctx := createTestContext(env)
@@ -271,6 +335,7 @@ func createTestContext(env map[string]string) ArgumentsContext {
 	desc := descriptor{}
 	limits := util.CGroupLimits{
 		MemoryLimitInBytes: 1024 * 1024 * 1024 * 8,
+		CoresEstimated:     3.5,
 		MaxCoresEstimated:  4,
 	}
 	env["JOLOKIA_PATH"] = "jolokia.jar"
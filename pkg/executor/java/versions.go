@@ -0,0 +1,144 @@
+package java
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/schema"
+)
+
+const (
+	descriptorType = "JavaDescriptor"
+	// currentDescriptorVersion : the version of descriptor and descriptorData
+	currentDescriptorVersion = "1"
+	// legacyDescriptorVersion : descriptors without Version were written before versions were checked
+	legacyDescriptorVersion = "1"
+)
+
+// descriptorVersion : how to decode a version of the descriptor, and upgrade it to the current one
+type descriptorVersion struct {
+	// shape is what the version looks like, and is used for validation
+	shape   interface{}
+	upgrade func(document *schema.Document) (descriptor, error)
+}
+
+// descriptorVersions : every version radish has supported. Old versions are kept, so images built years ago still
+// start. When descriptorData changes in a way old descriptors can not be read as, copy the current shape to a type for
+// the old version, add the new version here, and make the old one upgrade to it.
+var descriptorVersions = map[string]descriptorVersion{
+	"1": {shape: descriptor{}, upgrade: decodeCurrent},
+}
+
+// descriptorHeader : the fields every version has
+type descriptorHeader struct {
+	Type    string
+	Version string
+}
+
+func decodeCurrent(document *schema.Document) (descriptor, error) {
+	var desc descriptor
+	err := document.Decode(&desc)
+	return desc, err
+}
+
+// findDescriptorVersion : the version of the document, and how to decode it
+func findDescriptorVersion(document *schema.Document) (string, descriptorVersion, error) {
+	var header descriptorHeader
+	if err := document.Decode(&header); err != nil {
+		return "", descriptorVersion{}, err
+	}
+	if header.Type != "" && header.Type != descriptorType {
+		return "", descriptorVersion{}, errors.Errorf("Type is %s, expected %s", header.Type, descriptorType)
+	}
+	version := header.Version
+	if version == "" {
+		version = legacyDescriptorVersion
+	}
+	v, exists := descriptorVersions[version]
+	if !exists {
+		return "", descriptorVersion{}, errors.Errorf("Unsupported descriptor version %s. Supported versions are %s",
+			version, strings.Join(supportedDescriptorVersions(), ", "))
+	}
+	return version, v, nil
+}
+
+func supportedDescriptorVersions() []string {
+	versions := make([]string, 0, len(descriptorVersions))
+	for version := range descriptorVersions {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// decodeDescriptor : decodes any version of a JSON, YAML or TOML descriptor, and upgrades it to the current version
+func decodeDescriptor(filename string, dat []byte) (descriptor, error) {
+	document, err := schema.Parse(filename, dat)
+	if err != nil {
+		return descriptor{}, err
+	}
+	version, v, err := findDescriptorVersion(document)
+	if err != nil {
+		return descriptor{}, err
+	}
+	if err := schema.Report("Radish descriptor", document.Validate(v.shape)); err != nil {
+		return descriptor{}, err
+	}
+	if version != currentDescriptorVersion {
+		logrus.Infof("Radish descriptor version %s is upgraded to %s. Run radish migrateDescriptor to update it", version, currentDescriptorVersion)
+	}
+	return v.upgrade(document)
+}
+
+// ValidateDescriptor : the unknown fields, type mismatches and syntax errors in a radish descriptor, checked against
+// the shape of its version
+func ValidateDescriptor(filename string, data []byte) []schema.Problem {
+	document, err := schema.Parse(filename, data)
+	if err != nil {
+		return schema.ValidateFile(filename, data, descriptor{})
+	}
+	// Syntax errors, or a Version that is not a string, are reported with their position
+	if err := document.Decode(&descriptorHeader{}); err != nil {
+		return document.Validate(descriptor{})
+	}
+	_, v, err := findDescriptorVersion(document)
+	if err != nil {
+		return []schema.Problem{{Message: err.Error()}}
+	}
+	return document.Validate(v.shape)
+}
+
+// MigrateDescriptor : upgrades a descriptor to the current version. It is written in format, or in the format of
+// the descriptor when format is empty.
+func MigrateDescriptor(filename string, data []byte, format schema.Format) ([]byte, error) {
+	document, err := schema.Parse(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	_, v, err := findDescriptorVersion(document)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := v.upgrade(document)
+	if err != nil {
+		return nil, err
+	}
+	desc.Type = descriptorType
+	desc.Version = currentDescriptorVersion
+	dat, err := json.Marshal(desc)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = document.Format
+	}
+	migrated, err := schema.Encode(dat, format)
+	if err != nil {
+		return nil, fmt.Errorf("Could not write descriptor as %s: %s", format, err)
+	}
+	return migrated, nil
+}
@@ -0,0 +1,155 @@
+package java
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/skatteetaten/radish/pkg/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescriptorWithoutVersionIsVersion1(t *testing.T) {
+	desc, err := decodeDescriptor("radish.json", []byte(`{
+  "Type": "JavaDescriptor",
+  "Data": {
+    "MainClass": "foo.bar.Main",
+    "ApplicationArgs": "--a \"b c\""
+  }
+}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "--a \"b c\"", desc.Data.ApplicationArgs)
+
+	_, v, err := findDescriptorVersion(mustParse(t, "radish.json", `{"Type": "JavaDescriptor"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, descriptor{}, v.shape)
+}
+
+func mustParse(t *testing.T, filename string, data string) *schema.Document {
+	document, err := schema.Parse(filename, []byte(data))
+	assert.NoError(t, err)
+	return document
+}
+
+func TestDescriptorWithUnsupportedVersionOrType(t *testing.T) {
+	_, err := decodeDescriptor("radish.json", []byte(`{"Type": "JavaDescriptor", "Version": "99"}`))
+	assert.EqualError(t, err, "Unsupported descriptor version 99. Supported versions are 1")
+
+	_, err = decodeDescriptor("radish.json", []byte(`{"Type": "NodeDescriptor", "Version": "1"}`))
+	assert.EqualError(t, err, "Type is NodeDescriptor, expected JavaDescriptor")
+
+	problems := ValidateDescriptor("radish.json", []byte(`{"Type": "JavaDescriptor", "Version": "99"}`))
+	assert.Len(t, problems, 1)
+	assert.Equal(t, "Unsupported descriptor version 99. Supported versions are 1", problems[0].Message)
+}
+
+func TestValidateDescriptorUsesTheShapeOfItsVersion(t *testing.T) {
+	assert.Empty(t, ValidateDescriptor("radish.json", []byte(`{"Version": "1", "Data": {"JavaOptions": "-Dfoo=bar"}}`)))
+
+	problems := ValidateDescriptor("radish.json", []byte(`{"Version": "1", "Data": {"JavaOptions": ["-Dfoo=bar"]}}`))
+	assert.Len(t, problems, 1)
+	assert.Equal(t, "1:42: Data.JavaOptions: expected string, got array", problems[0].String())
+}
+
+// descriptorV0 : a made up older version, where JavaOptions was a list
+type descriptorV0 struct {
+	Type    string
+	Version string
+	Data    struct {
+		MainClass   string
+		JavaOptions []string
+	}
+}
+
+func registerDescriptorV0(t *testing.T) {
+	descriptorVersions["0"] = descriptorVersion{
+		shape: descriptorV0{},
+		upgrade: func(document *schema.Document) (descriptor, error) {
+			var old descriptorV0
+			if err := document.Decode(&old); err != nil {
+				return descriptor{}, err
+			}
+			return descriptor{Data: descriptorData{MainClass: old.Data.MainClass, JavaOptions: shellquote.Join(old.Data.JavaOptions...)}}, nil
+		},
+	}
+	t.Cleanup(func() {
+		delete(descriptorVersions, "0")
+	})
+}
+
+func TestOlderDescriptorVersionIsUpgraded(t *testing.T) {
+	registerDescriptorV0(t)
+	dat := []byte(`{"Type": "JavaDescriptor", "Version": "0", "Data": {"MainClass": "foo.bar.Main", "JavaOptions": ["-Dfoo=bar baz", "-Xss1m"]}}`)
+
+	assert.Empty(t, ValidateDescriptor("radish.json", dat))
+	desc, err := decodeDescriptor("radish.json", dat)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo.bar.Main", desc.Data.MainClass)
+	assert.Equal(t, "'-Dfoo=bar baz' -Xss1m", desc.Data.JavaOptions)
+
+	migrated, err := MigrateDescriptor("radish.json", dat, "")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+  "Type": "JavaDescriptor",
+  "Version": "1",
+  "Data": {
+    "MainClass": "foo.bar.Main",
+    "JavaOptions": "'-Dfoo=bar baz' -Xss1m"
+  }
+}`, string(migrated))
+	assert.Empty(t, ValidateDescriptor("radish.json", migrated))
+}
+
+func TestMigrateDescriptor(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testconfig.json")
+	assert.NoError(t, err)
+
+	migrated, err := MigrateDescriptor("testdata/testconfig.json", dat, "")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+  "Type": "JavaDescriptor",
+  "Version": "1",
+  "Data": {
+    "BaseDir": "testdata",
+    "PathsToClassLibraries": ["lib", "lib/lib2/lib4.jar"],
+    "MainClass": "foo.bar.Main",
+    "ApplicationArgs": "--logging.config=logback.xml",
+    "JavaOptions": "-Dfoo=bar",
+    "StartScript": "start.sh"
+  }
+}`, string(migrated))
+	assert.Empty(t, ValidateDescriptor("radish.json", migrated))
+
+	again, err := MigrateDescriptor("radish.json", migrated, "")
+	assert.NoError(t, err)
+	assert.Equal(t, string(migrated), string(again))
+}
+
+func TestMigrateDescriptorKeepsOrChangesFormat(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testconfig.yaml")
+	assert.NoError(t, err)
+
+	migrated, err := MigrateDescriptor("testdata/testconfig.yaml", dat, "")
+	assert.NoError(t, err)
+	assert.Equal(t, `Type: JavaDescriptor
+Version: "1"
+Data:
+  BaseDir: testdata
+  PathsToClassLibraries:
+    - lib
+    - lib/lib2/lib4.jar
+  MainClass: foo.bar.Main
+  ApplicationArgs: --logging.config=logback.xml
+  JavaOptions: -Dfoo=bar
+  ExitCodeMapping:
+    "143": 0
+`, string(migrated))
+
+	migrated, err = MigrateDescriptor("testdata/testconfig.yaml", dat, schema.TOML)
+	assert.NoError(t, err)
+	desc, err := decodeDescriptor("radish.toml", migrated)
+	assert.NoError(t, err)
+	assert.Equal(t, "-Dfoo=bar", desc.Data.JavaOptions)
+	assert.Equal(t, map[string]int{"143": 0}, desc.Data.ExitCodeMapping)
+	assert.Empty(t, ValidateDescriptor("radish.toml", migrated))
+}
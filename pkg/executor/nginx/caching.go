@@ -0,0 +1,243 @@
+package nginx
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nginxCaching : long immutable caching of fingerprinted assets, and no-cache for index.html
+type nginxCaching struct {
+	Enabled bool `json:"enabled"`
+	// MaxAge : seconds fingerprinted assets are cached. Default is one year
+	MaxAge int `json:"maxAge"`
+	// AssetNames : the naming conventions to cache. Detected from the content when empty
+	AssetNames []string `json:"assetNames"`
+}
+
+const maxCacheAge = 31536000
+
+// assetNaming : how a bundler fingerprints file names. nginx matches with PCRE, which can look ahead for a digit,
+// RE2 can not, so the digit in the hash is checked in hasHash.
+type assetNaming struct {
+	example string
+	pattern *regexp.Regexp
+	nginx   string
+}
+
+var assetNamings = map[string]assetNaming{
+	// webpack and create-react-app: main.3f2a1b9c.js, main.3f2a1b9c.chunk.js
+	"name.hash": {
+		example: "main.3f2a1b9c.js",
+		pattern: regexp.MustCompile(`\.([0-9a-f]{8,})(\.[A-Za-z0-9]+)+$`),
+		nginx:   `\.(?=[0-9a-f]*[0-9])[0-9a-f]{8,}(\.[A-Za-z0-9]+)+$`,
+	},
+	// vite and rollup: index-B2x9a_Kq.js
+	"name-hash": {
+		example: "index-B2x9a_Kq.js",
+		pattern: regexp.MustCompile(`-([A-Za-z0-9_-]{8})\.[A-Za-z0-9]+$`),
+		nginx:   `-(?=[A-Za-z0-9_-]{0,7}[0-9])[A-Za-z0-9_-]{8}\.[A-Za-z0-9]+$`,
+	},
+}
+
+func (n assetNaming) hasHash(name string) bool {
+	match := n.pattern.FindStringSubmatch(name)
+	return match != nil && strings.ContainsAny(match[1], "0123456789")
+}
+
+func sortedAssetNamings() []string {
+	var names []string
+	for name := range assetNamings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateCaching(caching nginxCaching) error {
+	if caching.MaxAge < 0 || caching.MaxAge > maxCacheAge {
+		return errors.Errorf("Caching maxAge %d should be between 0 (default) and %d", caching.MaxAge, maxCacheAge)
+	}
+	for _, name := range caching.AssetNames {
+		if _, exists := assetNamings[name]; !exists {
+			return errors.Errorf("Caching assetNames %s is not supported. Use %s", name, strings.Join(sortedAssetNamings(), " or "))
+		}
+	}
+	return nil
+}
+
+/*
+The content is scanned where it is built, web.webapp.content relative to the working directory, and where it is served
+from in the image. The first that exists is used.
+*/
+func contentDirectory(content string, documentRoot string, path string) (string, error) {
+	var candidates []string
+	if content != "" {
+		candidates = append(candidates, content)
+	}
+	candidates = append(candidates, filepath.Join(documentRoot, path))
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", errors.Errorf("Caching is enabled, but none of the content directories %s exist", strings.Join(candidates, ", "))
+}
+
+// cachedAssets : the naming conventions and fingerprinted files found in the content directory
+type cachedAssets struct {
+	maxAge  int
+	namings []string
+	// files : relative to the content directory, with forward slashes
+	files []string
+}
+
+// detectAssets : the naming conventions used by the files in dir. Every convention in wanted must be used.
+func detectAssets(dir string, wanted []string) ([]string, []string, error) {
+	candidates := wanted
+	if len(candidates) == 0 {
+		candidates = sortedAssetNamings()
+	}
+	found := map[string]bool{}
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		for _, name := range candidates {
+			if assetNamings[name].hasHash(entry.Name()) {
+				found[name] = true
+				relative, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, filepath.ToSlash(relative))
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "Error scanning %s", dir)
+	}
+
+	var detected []string
+	for _, name := range candidates {
+		if found[name] {
+			detected = append(detected, name)
+		} else if len(wanted) > 0 {
+			return nil, nil, errors.Errorf("Caching assetNames %s matches no files in %s. Expected names like %s", name, dir, assetNamings[name].example)
+		}
+	}
+	if len(detected) == 0 {
+		var examples []string
+		for _, name := range candidates {
+			examples = append(examples, assetNamings[name].example)
+		}
+		return nil, nil, errors.Errorf("Caching is enabled, but no fingerprinted assets like %s were found in %s", strings.Join(examples, " or "), dir)
+	}
+	return detected, files, nil
+}
+
+// configureCaching : the fingerprinted assets of the webapp, or nil when caching is not enabled
+func configureCaching(web Web, documentRoot string, path string) (*cachedAssets, error) {
+	if !web.Caching.Enabled {
+		return nil, nil
+	}
+	if err := validateCaching(web.Caching); err != nil {
+		return nil, err
+	}
+	dir, err := contentDirectory(web.WebApp.Content, documentRoot, path)
+	if err != nil {
+		return nil, err
+	}
+	namings, files, err := detectAssets(dir, web.Caching.AssetNames)
+	if err != nil {
+		return nil, err
+	}
+	maxAge := web.Caching.MaxAge
+	if maxAge == 0 {
+		maxAge = maxCacheAge
+	}
+	return &cachedAssets{maxAge: maxAge, namings: namings, files: files}, nil
+}
+
+/*
+A location in locations wins over the webapp location by longest prefix, so the caching locations are nested in the
+locations that cover a fingerprinted file too.
+*/
+func (c *cachedAssets) covers(location string) bool {
+	if c == nil {
+		return false
+	}
+	for _, file := range c.files {
+		if strings.HasPrefix(file, strings.TrimPrefix(location, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// inheritedHeaders : nginx does not inherit add_header in a location with its own, so the headers are repeated,
+// except Cache-Control
+func inheritedHeaders(own headers, security string) string {
+	indent := strings.Repeat("\t", 4)
+	inherited := ""
+	for _, key := range own.sort() {
+		if strings.EqualFold(key, "Cache-Control") {
+			continue
+		}
+		inherited = fmt.Sprintf("%s%sadd_header %s \"%s\";\n", inherited, indent, key, own[key])
+	}
+	for _, line := range strings.SplitAfter(security, "\n") {
+		if line != "" {
+			inherited = inherited + indent + strings.TrimLeft(line, "\t")
+		}
+	}
+	return inherited
+}
+
+func (c *cachedAssets) locations(inherited string) string {
+	indentN1 := strings.Repeat("\t", 3)
+	indentN2 := strings.Repeat("\t", 4)
+
+	sumLocations := ""
+	for _, name := range c.namings {
+		singleLocation := fmt.Sprintf("%slocation ~ \"%s\" {\n", indentN1, assetNamings[name].nginx)
+		singleLocation = fmt.Sprintf("%s%sadd_header Cache-Control \"public, max-age=%d, immutable\";\n", singleLocation, indentN2, c.maxAge)
+		singleLocation = fmt.Sprintf("%s%s%s}\n", singleLocation, inherited, indentN1)
+		sumLocations = sumLocations + singleLocation
+	}
+	return sumLocations
+}
+
+/*
+The caching locations are nested in the webapp location, so they never match a proxy. The index.html rule is left out
+when locations has an index.html of its own.
+*/
+func nginxCachingToString(assets *cachedAssets, path string, webappHeaders headers, security string, customIndex bool) string {
+	if assets == nil {
+		return ""
+	}
+	indentN1 := strings.Repeat("\t", 3)
+	indentN2 := strings.Repeat("\t", 4)
+
+	inherited := inheritedHeaders(webappHeaders, security)
+	sumLocations := assets.locations(inherited)
+	if !customIndex {
+		singleLocation := fmt.Sprintf("%slocation = %sindex.html {\n", indentN1, path)
+		singleLocation = fmt.Sprintf("%s%sadd_header Cache-Control \"no-cache\";\n", singleLocation, indentN2)
+		singleLocation = fmt.Sprintf("%s%s%s}\n", singleLocation, inherited, indentN1)
+		sumLocations = sumLocations + singleLocation
+	}
+	return sumLocations
+}
@@ -0,0 +1,177 @@
+package nginx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const expectedNginxConfWithCachingPartial = `
+	server {
+		listen 8080;
+
+		location /api {
+		return 404;
+		}
+
+		location /web/ {
+			root /u01/static;
+			try_files $uri /web/index.html;
+			add_header Cache-Control "max-age=60";
+			add_header SomeHeader "SomeValue";
+			location ~ "\.(?=[0-9a-f]*[0-9])[0-9a-f]{8,}(\.[A-Za-z0-9]+)+$" {
+				add_header Cache-Control "public, max-age=31536000, immutable";
+				add_header SomeHeader "SomeValue";
+			}
+			location = /web/index.html {
+				add_header Cache-Control "no-cache";
+				add_header SomeHeader "SomeValue";
+			}
+		}
+
+		location =/ {
+			if ($request_method = HEAD) {
+				return 200;
+			}
+			return 404 "Application is served under /web/";
+		}
+	}
+}
+`
+
+func createContent(t *testing.T, files ...string) string {
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte{}, 0644))
+	}
+	return dir
+}
+
+func TestGenerateNginxConfigurationWithCaching(t *testing.T) {
+	content := createContent(t, "index.html", "favicon.ico", "static/js/main.3f2a1b9c.chunk.js", "static/css/main.0d1e2f34.css")
+	openshiftConfig := OpenshiftConfig{
+		Web: Web{
+			WebApp: WebApp{
+				Content: content,
+				Path:    "/web",
+				Headers: map[string]string{
+					"SomeHeader":    "SomeValue",
+					"Cache-Control": "max-age=60",
+				},
+			},
+			Caching: nginxCaching{Enabled: true},
+		},
+	}
+
+	var actual string
+	err := generateNginxConfiguration(openshiftConfig, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cleanString(nginxConfPrefix+expectedNginxConfWithCachingPartial), cleanString(actual))
+
+	validateNginxConfig(t, actual)
+}
+
+func TestCachingRepeatsSecurityHeadersAndKeepsCustomIndex(t *testing.T) {
+	content := createContent(t, "index.html", "assets/index-B2x9a_Kq.js")
+	openshiftConfig := OpenshiftConfig{
+		Web: Web{
+			WebApp:   WebApp{Content: content},
+			Security: nginxSecurity{Preset: "none", Csp: map[string][]string{"default-src": {"'self'"}}},
+			Caching:  nginxCaching{Enabled: true, MaxAge: 600},
+			Locations: nginxLocations{
+				"index.html": {Headers: headers{"Cache-Control": "no-store"}},
+			},
+		},
+	}
+
+	input, err := mapDataDescToTemplateInput(openshiftConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "\t\t\tlocation ~ \"-(?=[A-Za-z0-9_-]{0,7}[0-9])[A-Za-z0-9_-]{8}\\.[A-Za-z0-9]+$\" {\n"+
+		"\t\t\t\tadd_header Cache-Control \"public, max-age=600, immutable\";\n"+
+		"\t\t\t\tadd_header Content-Security-Policy \"default-src 'self'\" always;\n"+
+		"\t\t\t}\n", input.CachingLocations)
+	assert.Contains(t, input.Locations, "location /index.html {")
+}
+
+func TestCachingIsNestedInLocationsCoveringAssets(t *testing.T) {
+	content := createContent(t, "index.html", "static/js/main.3f2a1b9c.chunk.js", "fonts/roboto.woff2")
+	openshiftConfig := OpenshiftConfig{
+		Web: Web{
+			WebApp:   WebApp{Content: content, Path: "/web"},
+			Security: nginxSecurity{Preset: "none"},
+			Caching:  nginxCaching{Enabled: true},
+			Locations: nginxLocations{
+				"static/": {Headers: headers{"Cache-Control": "max-age=60", "X-Static": "true"}},
+				"fonts/":  {Headers: headers{"Cache-Control": "max-age=600"}},
+			},
+		},
+	}
+
+	input, err := mapDataDescToTemplateInput(openshiftConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "\t\tlocation /web/fonts/ {\n"+
+		"\t\t\troot /u01/static;\n"+
+		"\t\t\tadd_header Cache-Control \"max-age=600\";\n"+
+		"\t\t}\n"+
+		"\t\tlocation /web/static/ {\n"+
+		"\t\t\troot /u01/static;\n"+
+		"\t\t\tadd_header Cache-Control \"max-age=60\";\n"+
+		"\t\t\tadd_header X-Static \"true\";\n"+
+		"\t\t\tlocation ~ \"\\.(?=[0-9a-f]*[0-9])[0-9a-f]{8,}(\\.[A-Za-z0-9]+)+$\" {\n"+
+		"\t\t\t\tadd_header Cache-Control \"public, max-age=31536000, immutable\";\n"+
+		"\t\t\t\tadd_header X-Static \"true\";\n"+
+		"\t\t\t}\n"+
+		"\t\t}\n", input.Locations)
+
+	var actual string
+	assert.NoError(t, generateNginxConfiguration(openshiftConfig, testFileWriter(&actual)))
+	validateNginxConfig(t, actual)
+}
+
+func TestCachingFailsWhenNoAssetsAreFingerprinted(t *testing.T) {
+	content := createContent(t, "index.html", "some-function.js", "main.js", "jquery.min.js")
+	web := Web{WebApp: WebApp{Content: content}, Caching: nginxCaching{Enabled: true}}
+
+	_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: web})
+	assert.EqualError(t, err, "Caching is enabled, but no fingerprinted assets like index-B2x9a_Kq.js or main.3f2a1b9c.js were found in "+content)
+
+	content = createContent(t, "index.html", "main.3f2a1b9c.js")
+	web = Web{WebApp: WebApp{Content: content}, Caching: nginxCaching{Enabled: true, AssetNames: []string{"name.hash", "name-hash"}}}
+	_, err = mapDataDescToTemplateInput(OpenshiftConfig{Web: web})
+	assert.EqualError(t, err, "Caching assetNames name-hash matches no files in "+content+". Expected names like index-B2x9a_Kq.js")
+}
+
+func TestInvalidCachingIsPrevented(t *testing.T) {
+	web := Web{WebApp: WebApp{Content: "testdata/does-not-exist", Path: "/web"}, Caching: nginxCaching{Enabled: true}}
+	_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: web})
+	assert.EqualError(t, err, "Caching is enabled, but none of the content directories testdata/does-not-exist, /u01/static/web exist")
+
+	web.Caching.AssetNames = []string{"name_hash"}
+	_, err = mapDataDescToTemplateInput(OpenshiftConfig{Web: web})
+	assert.EqualError(t, err, "Caching assetNames name_hash is not supported. Use name-hash or name.hash")
+
+	web.Caching = nginxCaching{Enabled: true, MaxAge: 2 * maxCacheAge}
+	_, err = mapDataDescToTemplateInput(OpenshiftConfig{Web: web})
+	assert.EqualError(t, err, "Caching maxAge 63072000 should be between 0 (default) and 31536000")
+}
+
+func TestAssetNamings(t *testing.T) {
+	dotHash := assetNamings["name.hash"]
+	assert.True(t, dotHash.hasHash("main.3f2a1b9c.js"))
+	assert.True(t, dotHash.hasHash("2.1a2b3c4d.chunk.css"))
+	assert.False(t, dotHash.hasHash("main.deadbeef.js"))
+	assert.False(t, dotHash.hasHash("jquery.min.js"))
+
+	dashHash := assetNamings["name-hash"]
+	assert.True(t, dashHash.hasHash("index-B2x9a_Kq.js"))
+	assert.True(t, dashHash.hasHash("vendor-lib-a1-b2c3d.css"))
+	assert.False(t, dashHash.hasHash("some-function.js"))
+	assert.False(t, dashHash.hasHash("index.js"))
+}
@@ -1,8 +1,12 @@
 package nginx
 
 import (
-	"encoding/json"
+	"fmt"
 	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/radish/pkg/schema"
 )
 
 // Docker :
@@ -19,12 +23,18 @@ type Web struct {
 	Gzip              nginxGzip      `json:"gzip"`
 	Exclude           []string       `json:"exclude"`
 	Locations         nginxLocations `json:"locations"`
+	Proxies           []nginxProxy   `json:"proxies"`
+	LogFormat         string         `json:"logFormat"`
+	Security          nginxSecurity  `json:"security"`
+	Caching           nginxCaching   `json:"caching"`
+	ExitCodeMapping   map[string]int `json:"exitCodeMapping"`
 }
 
-// Nodejs :
+// Nodejs : Overrides and Websocket apply to the /api location
 type Nodejs struct {
 	Main      string            `json:"main"`
 	Overrides map[string]string `json:"overrides"`
+	Websocket bool              `json:"websocket"`
 }
 
 // WebApp :
@@ -46,17 +56,60 @@ type headers map[string]string
 type nginxLocations map[string]*nginxLocation
 
 type nginxLocation struct {
-	Headers headers   `json:"headers"`
-	Gzip    nginxGzip `json:"gzip"`
+	Headers  headers        `json:"headers"`
+	Gzip     nginxGzip      `json:"gzip"`
+	Security *nginxSecurity `json:"security"`
 }
 
 type nginxGzip struct {
 	UseStatic string `json:"use_static"`
 }
 
-// UnmarshallOpenshiftConfig :
+// UnmarshallOpenshiftConfig : JSON, YAML or TOML, detected from the content. Unknown fields fail, or are logged as
+// warnings when RADISH_STRICT_DESCRIPTOR is false.
 func UnmarshallOpenshiftConfig(buffer io.Reader) (OpenshiftConfig, error) {
+	dat, err := io.ReadAll(buffer)
+	if err != nil {
+		return OpenshiftConfig{}, err
+	}
+	return decodeOpenshiftConfig("", dat)
+}
+
+func decodeOpenshiftConfig(filename string, dat []byte) (OpenshiftConfig, error) {
 	var data OpenshiftConfig
-	err := json.NewDecoder(buffer).Decode(&data)
-	return data, err
+	document, err := schema.Parse(filename, dat)
+	if err != nil {
+		return data, err
+	}
+	if err := document.Decode(&data); err != nil {
+		return data, err
+	}
+	if err := schema.Report("Radish config", document.Validate(OpenshiftConfig{})); err != nil {
+		return OpenshiftConfig{}, err
+	}
+	return data, nil
+}
+
+// ValidateOpenshiftConfig : the unknown fields, type mismatches and syntax errors in a radish config
+func ValidateOpenshiftConfig(filename string, data []byte) []schema.Problem {
+	return schema.ValidateFile(filename, data, OpenshiftConfig{})
+}
+
+// OpenshiftConfigSchema : the JSON Schema of the radish config used by nginx and nodejs
+func OpenshiftConfigSchema() map[string]interface{} {
+	return schema.Generate(OpenshiftConfig{}, "Radish web config")
+}
+
+// ReadOpenshiftConfig : JSON, YAML or TOML, detected from the extension or content
+func ReadOpenshiftConfig(openshiftConfigPath string) (OpenshiftConfig, error) {
+	data, err := os.ReadFile(openshiftConfigPath)
+	if err != nil {
+		return OpenshiftConfig{}, fmt.Errorf("Error reading file: " + openshiftConfigPath)
+	}
+
+	openshiftConfig, err := decodeOpenshiftConfig(openshiftConfigPath, data)
+	if err != nil {
+		return OpenshiftConfig{}, errors.Wrap(err, "Error mapping openshift json to internal structure")
+	}
+	return openshiftConfig, nil
 }
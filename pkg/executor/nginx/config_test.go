@@ -0,0 +1,61 @@
+package nginx
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOpenshiftConfig(t *testing.T) {
+	dat, err := os.ReadFile("testdata/testRadishConfig.json")
+	assert.NoError(t, err)
+	assert.Empty(t, ValidateOpenshiftConfig("testdata/testRadishConfig.json", dat))
+
+	dat, err = os.ReadFile("testdata/testRadishConfigWithTypo.json")
+	assert.NoError(t, err)
+	problems := ValidateOpenshiftConfig("testdata/testRadishConfigWithTypo.json", dat)
+	assert.Len(t, problems, 1)
+	assert.Equal(t, `6:12: web.webapp: unknown field "disableTryFile", did you mean "disableTryfiles"?`, problems[0].String())
+}
+
+func TestUnmarshallOpenshiftConfigIgnoresUnknownFieldsWhenNotStrict(t *testing.T) {
+	t.Setenv("RADISH_STRICT_DESCRIPTOR", "false")
+	f, err := os.Open("testdata/testRadishConfigWithTypo.json")
+	assert.NoError(t, err)
+	defer f.Close()
+
+	config, err := UnmarshallOpenshiftConfig(f)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "build", config.Web.WebApp.Content)
+	assert.False(t, config.Web.WebApp.DisableTryfiles)
+}
+
+func TestGenerateNginxConfigurationFailsOnUnknownFields(t *testing.T) {
+	err := GenerateNginxConfiguration("testdata/testRadishConfigWithTypo.json", t.TempDir()+"/nginx.conf")
+
+	assert.EqualError(t, err, `Error mapping openshift json to internal structure: `+
+		`Radish config has problems: 6:12: web.webapp: unknown field "disableTryFile", did you mean "disableTryfiles"?. `+
+		`Set RADISH_STRICT_DESCRIPTOR=false to only log them`)
+}
+
+func TestPublishedOpenshiftConfigSchemaIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../../../schemas/web-config.schema.json")
+	assert.NoError(t, err)
+	generated, err := json.MarshalIndent(OpenshiftConfigSchema(), "", "  ")
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(generated), string(published),
+		"Run radish validateDescriptor --print-schema --type web > schemas/web-config.schema.json")
+}
+
+func TestReadOpenshiftConfigInYAML(t *testing.T) {
+	config, err := ReadOpenshiftConfig("testdata/testRadishConfig.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "build", config.Web.WebApp.Content)
+	assert.Equal(t, "/web", config.Web.WebApp.Path)
+	assert.Equal(t, map[string]string{"client_max_body_size": "10m"}, config.Web.Nodejs.Overrides)
+	assert.Equal(t, map[string]string{"SomeHeader": "SomeValue"}, config.Web.WebApp.Headers)
+}
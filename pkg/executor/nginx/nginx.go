@@ -1,7 +1,6 @@
 package nginx
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"regexp"
@@ -29,9 +28,25 @@ http {
 	log_format  main  '$remote_addr - $remote_user [$time_local] "$request" '
 						'$status $body_bytes_sent "$http_referer" '
 						'"$http_user_agent" "$http_x_forwarded_for"';
-
-	access_log  /dev/stdout;
-	{{if .LogToFile}}access_log /u01/logs/nginx.access;{{end}}
+{{if eq .LogFormat "json"}}
+	log_format  json  escape=json '{'
+						'"time":"$time_iso8601",'
+						'"remote_addr":"$remote_addr",'
+						'"request_method":"$request_method",'
+						'"request_uri":"$request_uri",'
+						'"status":$status,'
+						'"body_bytes_sent":$body_bytes_sent,'
+						'"request_time":$request_time,'
+						'"upstream_response_time":"$upstream_response_time",'
+						'"http_referer":"$http_referer",'
+						'"http_user_agent":"$http_user_agent",'
+						'"request_id":"$http_x_request_id",'
+						'"traceparent":"$http_traceparent",'
+						'"b3_trace_id":"$http_x_b3_traceid"'
+						'}';
+{{end}}
+	access_log  /dev/stdout{{if ne .LogFormat "combined"}} {{.LogFormat}}{{end}};
+	{{if .LogToFile}}access_log /u01/logs/nginx.access{{if ne .LogFormat "combined"}} {{.LogFormat}}{{end}};{{end}}
 	sendfile        on;
 	#tcp_nopush     on;
 	server_tokens  off;
@@ -43,16 +58,27 @@ http {
 
 	index index.html;
 
+{{if .WebsocketMap}}
+	map $http_upgrade $connection_upgrade {
+		default upgrade;
+		'' close;
+	}
+{{end}}{{.Upstreams}}
 	server {
 		listen 8080;
-
+{{if .APILocation}}
 		location /api {
 		{{if .HasProxyPass }}proxy_pass http://{{.ProxyPassHost}}:{{.ProxyPassPort}};
-			proxy_http_version 1.1;{{else}}return 404;
+			proxy_http_version 1.1;{{if .APIWebsocket}}
+			proxy_set_header Upgrade $http_upgrade;
+			proxy_set_header Connection $connection_upgrade;
+			proxy_read_timeout {{.WebsocketTimeout}};
+			proxy_send_timeout {{.WebsocketTimeout}};{{end}}{{else}}return 404;
 		{{end}}{{range $key, $value := .NginxOverrides}}
 			{{$key}} {{$value}};{{end}}
 		}
-		{{range $value := .Exclude}}
+{{end}}
+{{.ProxyLocations}}		{{range $value := .Exclude}}
 		location {{$value}} {  
 			return 404;
 		}
@@ -64,7 +90,7 @@ http {
 			location {{.Path}} {
 			root /u01/static;{{end}}{{range $key, $value := .ExtraStaticHeaders}}
 			add_header {{$key}} "{{$value}}";{{end}}
-		}
+{{.SecurityHeaders}}{{.CachingLocations}}		}
 		
 		{{.Locations}}
 		{{if .NotServingOnRoot}}
@@ -90,14 +116,10 @@ func GenerateNginxConfiguration(openshiftConfigPath string, nginxPath string) er
 	var openshiftConfig OpenshiftConfig
 
 	if openshiftConfigPath != "" {
-		data, err := os.ReadFile(openshiftConfigPath)
-		if err != nil {
-			return fmt.Errorf("Error reading file: " + openshiftConfigPath)
-		}
-
-		openshiftConfig, err = UnmarshallOpenshiftConfig(bytes.NewBuffer(data))
+		var err error
+		openshiftConfig, err = ReadOpenshiftConfig(openshiftConfigPath)
 		if err != nil {
-			return fmt.Errorf("Error mapping openshift json to internal structure")
+			return err
 		}
 	} else {
 		return fmt.Errorf("OpenshiftConfigPath is missing. Will not generate nginx configuration with radish")
@@ -196,15 +218,60 @@ func mapDataDescToTemplateInput(openshiftConfig OpenshiftConfig) (*executor.Temp
 		return nil, err
 	}
 
+	// The /api location is replaced by a proxy on /api, unless it is already proxied to nodejs or PROXY_PASS_HOST
+	apiLocation := proxy.hasProxy || !hasProxyPath(openshiftConfig.Web.Proxies, apiPath)
+	reservedPaths := []string{path}
+	if apiLocation {
+		reservedPaths = append(reservedPaths, apiPath)
+	}
+	reservedPaths = append(reservedPaths, exclude...)
+	for _, key := range openshiftConfig.Web.Locations.sort() {
+		reservedPaths = append(reservedPaths, path+key)
+	}
+	err = validateProxies(openshiftConfig.Web.Proxies, reservedPaths)
+	if err != nil {
+		return nil, err
+	}
+
 	proxyReadTimeout := getEnvOrDefault("NGINX_PROXY_READ_TIMEOUT", "60")
+	websocketTimeout := getEnvOrDefault("NGINX_WEBSOCKET_TIMEOUT", "3600s")
+	if !proxyTimeoutPattern.MatchString(websocketTimeout) {
+		return nil, errors.Errorf("NGINX_WEBSOCKET_TIMEOUT %q should be on the form N, Nms, Ns or Nm", websocketTimeout)
+	}
+	apiWebsocket := openshiftConfig.Web.Nodejs.Websocket && proxy.hasProxy && apiLocation
 
 	workerConnections := getEnvOrDefault("NGINX_WORKER_CONNECTIONS", "1024")
 	workerProcesses := getEnvOrDefault("NGINX_WORKER_PROCESSES", "1")
 
 	nginxLogStrategy := getEnvOrDefault("NGINX_LOG_STRATEGY", "stdout")
+	nginxLogFormat, err := logFormat(openshiftConfig.Web.LogFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	security := openshiftConfig.Web.Security
+	err = validateSecurity(security)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range openshiftConfig.Web.Locations.sort() {
+		if location := openshiftConfig.Web.Locations[key]; location != nil && location.Security != nil {
+			if err := validateSecurity(*location.Security); err != nil {
+				return nil, errors.Wrapf(err, "Location %s", key)
+			}
+		}
+	}
+
+	securityHeaders := securityHeadersToString(security, openshiftConfig.Web.WebApp.Headers, strings.Repeat("\t", 3))
+	assets, err := configureCaching(openshiftConfig.Web, documentRoot, path)
+	if err != nil {
+		return nil, err
+	}
+	_, customIndex := openshiftConfig.Web.Locations["index.html"]
+	cachingLocations := nginxCachingToString(assets, path, openshiftConfig.Web.WebApp.Headers, securityHeaders, customIndex)
 
 	nginxGzipForTemplate := nginxGzipMapToString(openshiftConfig.Web.Gzip)
-	nginxLocationForTemplate := nginxLocationsMapToString(openshiftConfig.Web.Locations, documentRoot, path)
+	nginxLocationForTemplate := nginxLocationsMapToString(openshiftConfig.Web.Locations, documentRoot, path, security, assets)
 
 	notServingOnRoot := true
 	if path == "/" {
@@ -218,6 +285,8 @@ func mapDataDescToTemplateInput(openshiftConfig OpenshiftConfig) (*executor.Temp
 	return &executor.TemplateInput{
 		NginxOverrides:     openshiftConfig.Web.Nodejs.Overrides,
 		ExtraStaticHeaders: openshiftConfig.Web.WebApp.Headers,
+		SecurityHeaders:    securityHeaders,
+		CachingLocations:   cachingLocations,
 		SPA:                !openshiftConfig.Web.WebApp.DisableTryfiles,
 		Path:               path,
 		Gzip:               nginxGzipForTemplate,
@@ -226,14 +295,38 @@ func mapDataDescToTemplateInput(openshiftConfig OpenshiftConfig) (*executor.Temp
 		HasProxyPass:       proxy.hasProxy,
 		ProxyPassHost:      proxy.host,
 		ProxyPassPort:      proxy.port,
+		APILocation:        apiLocation,
+		Upstreams:          nginxUpstreamsToString(openshiftConfig.Web.Proxies),
+		ProxyLocations:     nginxProxiesToString(openshiftConfig.Web.Proxies, websocketTimeout),
+		APIWebsocket:       apiWebsocket,
+		WebsocketMap:       apiWebsocket || hasWebsocketProxy(openshiftConfig.Web.Proxies),
+		WebsocketTimeout:   websocketTimeout,
 		WorkerConnections:  workerConnections,
 		WorkerProcesses:    workerProcesses,
 		ProxyReadTimeout:   proxyReadTimeout,
 		NotServingOnRoot:   notServingOnRoot,
 		LogToFile:          logToFile,
+		LogFormat:          nginxLogFormat,
 	}, nil
 }
 
+// logFormats : the log_format names access logs can be written with. combined is built into nginx.
+var logFormats = []string{"combined", "main", "json"}
+
+// logFormat : NGINX_LOG_FORMAT wins over web.logFormat in the radish config. Default is combined.
+func logFormat(configured string) (string, error) {
+	format := strings.ToLower(getEnvOrDefault("NGINX_LOG_FORMAT", configured))
+	if format == "" {
+		return "combined", nil
+	}
+	for _, known := range logFormats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", errors.Errorf("Log format %s is not supported. Use one of %s", format, strings.Join(logFormats, ", "))
+}
+
 /*
 We sanitize the input.... Don't want to large inputs.
 
@@ -311,7 +404,7 @@ func (m headers) sort() []string {
 	return index
 }
 
-func nginxLocationsMapToString(m nginxLocations, documentRoot string, path string) string {
+func nginxLocationsMapToString(m nginxLocations, documentRoot string, path string, security nginxSecurity, assets *cachedAssets) string {
 	sumLocations := ""
 	indentN1 := strings.Repeat("\t", 2)
 	indentN2 := strings.Repeat("\t", 3)
@@ -330,6 +423,11 @@ func nginxLocationsMapToString(m nginxLocations, documentRoot string, path strin
 		for _, k2 := range value.Headers.sort() {
 			singleLocation = fmt.Sprintf("%s%sadd_header %s \"%s\";\n", singleLocation, indentN2, k2, value.Headers[k2])
 		}
+		locationSecurity := securityHeadersToString(security.override(value.Security), value.Headers, indentN2)
+		singleLocation = singleLocation + locationSecurity
+		if assets.covers(key) {
+			singleLocation = singleLocation + assets.locations(inheritedHeaders(value.Headers, locationSecurity))
+		}
 
 		singleLocation = fmt.Sprintf("%s%s}\n", singleLocation, indentN1)
 		sumLocations = sumLocations + singleLocation
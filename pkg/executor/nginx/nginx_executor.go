@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/executor"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,6 +16,8 @@ import (
 type Executor interface {
 	PrepareForNginxRun(nginxConfigPath string) *exec.Cmd
 	StartLogRotate(pid int)
+	LoadExitCodeMapping(openshiftConfigPath string) error
+	HandleExit(exitCode int, pid int) int
 }
 
 type nginxExecutor struct {
@@ -23,6 +26,7 @@ type nginxExecutor struct {
 }
 
 type nginxExitHandler struct {
+	exitCodeMapping executor.ExitCodeMapping
 }
 
 type nginxLogRotate struct {
@@ -33,7 +37,7 @@ type nginxLogRotate struct {
 
 // NewNginxExecutor :
 func NewNginxExecutor(rotateAfterSize int, checkRotateAfter int, logfiles []string) Executor {
-	return nginxExecutor{
+	return &nginxExecutor{
 		nginxExitHandler{},
 		nginxLogRotate{
 			paths:            logfiles,
@@ -51,6 +55,20 @@ func (m nginxExecutor) PrepareForNginxRun(nginxConfigPath string) *exec.Cmd {
 	return cmd
 }
 
+// LoadExitCodeMapping : openshiftConfigPath is optional
+func (m *nginxExitHandler) LoadExitCodeMapping(openshiftConfigPath string) error {
+	mapping, err := executor.LoadExitCodeMapping(openshiftConfigPath)
+	if err != nil {
+		return err
+	}
+	m.exitCodeMapping = mapping
+	return nil
+}
+
+func (m *nginxExitHandler) HandleExit(exitCode int, pid int) int {
+	return m.exitCodeMapping.Rewrite(exitCode)
+}
+
 func (m nginxLogRotate) StartLogRotate(pid int) {
 	ticker := time.NewTicker(time.Duration(m.checkRotateAfter) * time.Millisecond)
 	done := make(chan bool)
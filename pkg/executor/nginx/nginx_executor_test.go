@@ -136,3 +136,24 @@ func waitSig1(t *testing.T, c <-chan os.Signal, sig os.Signal, all bool) {
 	t.Fatalf("timeout after %v waiting for %v", settleTime, sig)
 
 }
+
+func TestHandleExitWithExitCodeMapping(t *testing.T) {
+	t.Setenv("RADISH_EXIT_CODE_MAPPING", "3=0")
+	e := NewNginxExecutor(0, 600, []string{})
+
+	err := e.LoadExitCodeMapping("testdata/testRadishConfigWithExitCodeMapping.json")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, e.HandleExit(143, 1))
+	assert.Equal(t, 0, e.HandleExit(3, 1))
+	assert.Equal(t, 1, e.HandleExit(1, 1))
+}
+
+func TestHandleExitWithoutOpenshiftConfig(t *testing.T) {
+	e := NewNginxExecutor(0, 600, []string{})
+
+	err := e.LoadExitCodeMapping("")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 143, e.HandleExit(143, 1))
+}
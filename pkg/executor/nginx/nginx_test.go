@@ -514,6 +514,36 @@ const expectedNginxConfigWithOverrides = `
 }
 `
 
+const expectedNginxConfigWithWebsocket = `
+	map $http_upgrade $connection_upgrade {
+		default upgrade;
+		'' close;
+	}
+
+	server {
+		listen 8080;
+
+		location /api {
+			proxy_pass http://localhost:9090;
+			proxy_http_version 1.1;
+			proxy_set_header Upgrade $http_upgrade;
+			proxy_set_header Connection $connection_upgrade;
+			proxy_read_timeout 3600s;
+			proxy_send_timeout 3600s;
+			client_max_body_size 5m;
+		}
+
+
+		location / {
+			root /u01/static;
+			try_files $uri /index.html;
+		}
+
+
+	}
+}
+`
+
 func TestGeneratedNginxFileWhenNodeJSIsEnabled(t *testing.T) {
 	openshiftJSON := OpenshiftConfig{
 		Docker: Docker{
@@ -669,6 +699,64 @@ func TestThatUnknownOverrideInNginxIsPrevented(t *testing.T) {
 	}
 }
 
+func TestThatWebsocketHeadersAreSetOnAPI(t *testing.T) {
+	openshiftJSON := OpenshiftConfig{
+		Web: Web{
+			Nodejs: Nodejs{
+				Main:      "test.json",
+				Websocket: true,
+				Overrides: map[string]string{
+					"client_max_body_size": "5m",
+				},
+			},
+		},
+	}
+
+	var actual string
+	err := generateNginxConfiguration(openshiftJSON, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cleanString(nginxConfPrefix+expectedNginxConfigWithWebsocket), cleanString(actual))
+
+	validateNginxConfig(t, actual)
+}
+
+func TestThatAccessLogFormatIsSet(t *testing.T) {
+	t.Setenv("NGINX_LOG_STRATEGY", "file")
+	openshiftJSON := OpenshiftConfig{
+		Web: Web{
+			LogFormat: "json",
+		},
+	}
+
+	var actual string
+	err := generateNginxConfiguration(openshiftJSON, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.Contains(t, actual, "log_format  json  escape=json '{'")
+	assert.Contains(t, actual, `'"request_id":"$http_x_request_id",'`)
+	assert.Contains(t, actual, "access_log  /dev/stdout json;")
+	assert.Contains(t, actual, "access_log /u01/logs/nginx.access json;")
+	validateNginxConfig(t, actual)
+
+	t.Setenv("NGINX_LOG_FORMAT", "Main")
+	err = generateNginxConfiguration(openshiftJSON, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.NotContains(t, actual, "log_format  json")
+	assert.Contains(t, actual, "access_log  /dev/stdout main;")
+	assert.Contains(t, actual, "access_log /u01/logs/nginx.access main;")
+}
+
+func TestThatUnknownAccessLogFormatIsPrevented(t *testing.T) {
+	t.Setenv("NGINX_LOG_FORMAT", "splunk")
+
+	var actual string
+	err := generateNginxConfiguration(OpenshiftConfig{}, testFileWriter(&actual))
+
+	assert.EqualError(t, err, "Error mapping data to template: Log format splunk is not supported. Use one of combined, main, json")
+}
+
 func TestGenerateNginxConfigurationFromDefaultTemplate(t *testing.T) {
 	_ = os.Setenv("NGINX_LOG_STRATEGY", "file")
 	err := GenerateNginxConfiguration("testdata/testRadishConfig.json", "testdata")
@@ -686,6 +774,8 @@ func TestGenerateNginxConfigurationFromDefaultTemplate(t *testing.T) {
 }
 
 func TestGenerateNginxConfigurationFromDefaultTemplateWithGzip(t *testing.T) {
+	// The config has gzip settings radish does not support, and is only read when they are logged
+	t.Setenv("RADISH_STRICT_DESCRIPTOR", "false")
 	_ = os.Setenv("NGINX_LOG_STRATEGY", "file")
 	err := GenerateNginxConfiguration("testdata/testRadishConfigWithGzipStatic.json", "testdata")
 	assert.Equal(t, nil, err)
@@ -768,6 +858,8 @@ func TestGenerateNginxConfigurationFromDefaultTemplateWithIgnoreExcludeNginxEnvP
 }
 
 func TestGenerateNginxConfigurationFromDefaultTemplateWithCustomLocations(t *testing.T) {
+	// The config has gzip settings radish does not support, and is only read when they are logged
+	t.Setenv("RADISH_STRICT_DESCRIPTOR", "false")
 	_ = os.Setenv("NGINX_LOG_STRATEGY", "file")
 	err := GenerateNginxConfiguration("testdata/testRadishConfigWithCustomLocations.json", "testdata")
 	assert.Equal(t, nil, err)
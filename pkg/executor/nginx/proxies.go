@@ -0,0 +1,163 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// apiPath : the location radish has always proxied to nodejs or PROXY_PASS_HOST
+const apiPath = "/api"
+
+// nginxProxy : a location that is proxied to its own upstream
+type nginxProxy struct {
+	Path           string `json:"path"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	ConnectTimeout string `json:"connectTimeout"`
+	ReadTimeout    string `json:"readTimeout"`
+	SendTimeout    string `json:"sendTimeout"`
+	Websocket      bool   `json:"websocket"`
+}
+
+var (
+	proxyPathPattern    = regexp.MustCompile(`^/[A-Za-z0-9._~/-]*$`)
+	proxyHostPattern    = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9.-]*[A-Za-z0-9])?$`)
+	proxyTimeoutPattern = regexp.MustCompile(`^[1-9][0-9]{0,4}(ms|s|m)?$`)
+	upstreamNameInvalid = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+/*
+Paths, hosts and timeouts end up in nginx.conf, so they are checked before they are written. A path that is already
+a location would make nginx refuse to start with a duplicate location.
+*/
+func validateProxies(proxies []nginxProxy, reservedPaths []string) error {
+	paths := map[string]bool{}
+	for _, reserved := range reservedPaths {
+		paths[reserved] = true
+	}
+	for _, proxy := range proxies {
+		if !proxyPathPattern.MatchString(proxy.Path) {
+			return errors.Errorf("Proxy path %q should start with / and only contain letters, digits and . _ ~ / -", proxy.Path)
+		}
+		if paths[proxy.Path] {
+			return errors.Errorf("Proxy path %s is already a location", proxy.Path)
+		}
+		paths[proxy.Path] = true
+
+		if !proxyHostPattern.MatchString(proxy.Host) {
+			return errors.Errorf("Proxy %s: host %q should be a host name or an IP address", proxy.Path, proxy.Host)
+		}
+		if proxy.Port < 1 || proxy.Port > 65535 {
+			return errors.Errorf("Proxy %s: port %d should be between 1 and 65535", proxy.Path, proxy.Port)
+		}
+		timeouts := []struct{ name, value string }{
+			{"connectTimeout", proxy.ConnectTimeout},
+			{"readTimeout", proxy.ReadTimeout},
+			{"sendTimeout", proxy.SendTimeout},
+		}
+		for _, timeout := range timeouts {
+			if timeout.value != "" && !proxyTimeoutPattern.MatchString(timeout.value) {
+				return errors.Errorf("Proxy %s: %s %q should be on the form N, Nms, Ns or Nm", proxy.Path, timeout.name, timeout.value)
+			}
+		}
+	}
+	return nil
+}
+
+// hasProxyPath : true when one of the proxies is served on path
+func hasProxyPath(proxies []nginxProxy, path string) bool {
+	for _, proxy := range proxies {
+		if proxy.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// hasWebsocketProxy : true when one of the proxies needs $connection_upgrade
+func hasWebsocketProxy(proxies []nginxProxy) bool {
+	for _, proxy := range proxies {
+		if proxy.Websocket {
+			return true
+		}
+	}
+	return false
+}
+
+// upstreamNames : a readable upstream name for each proxy, made from its path
+func upstreamNames(proxies []nginxProxy) []string {
+	names := make([]string, len(proxies))
+	used := map[string]bool{}
+	for i, proxy := range proxies {
+		name := strings.Trim(upstreamNameInvalid.ReplaceAllString(proxy.Path, "_"), "_")
+		if name == "" {
+			name = "root"
+		}
+		name = "radish_" + name
+		if used[name] {
+			name = fmt.Sprintf("%s_%d", name, i)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func nginxUpstreamsToString(proxies []nginxProxy) string {
+	sumUpstreams := ""
+	indentN1 := strings.Repeat("\t", 1)
+	indentN2 := strings.Repeat("\t", 2)
+
+	for i, name := range upstreamNames(proxies) {
+		upstream := fmt.Sprintf("%supstream %s {\n", indentN1, name)
+		upstream = fmt.Sprintf("%s%sserver %s:%d;\n", upstream, indentN2, proxies[i].Host, proxies[i].Port)
+		upstream = fmt.Sprintf("%s%s}\n", upstream, indentN1)
+		sumUpstreams = sumUpstreams + upstream
+	}
+	return sumUpstreams
+}
+
+// nginxProxiesToString : websocket proxies read and send with websocketTimeout, unless their own timeouts are set
+func nginxProxiesToString(proxies []nginxProxy, websocketTimeout string) string {
+	sumLocations := ""
+	indentN1 := strings.Repeat("\t", 2)
+	indentN2 := strings.Repeat("\t", 3)
+
+	for i, name := range upstreamNames(proxies) {
+		proxy := proxies[i]
+		singleLocation := fmt.Sprintf("%slocation %s {\n", indentN1, proxy.Path)
+		singleLocation = fmt.Sprintf("%s%sproxy_pass http://%s;\n", singleLocation, indentN2, name)
+		singleLocation = fmt.Sprintf("%s%sproxy_http_version 1.1;\n", singleLocation, indentN2)
+		// nginx sends the upstream name as Host, the backend expects the host it would get without an upstream
+		singleLocation = fmt.Sprintf("%s%sproxy_set_header Host %s:%d;\n", singleLocation, indentN2, proxy.Host, proxy.Port)
+
+		readTimeout := proxy.ReadTimeout
+		sendTimeout := proxy.SendTimeout
+		if proxy.Websocket {
+			singleLocation = fmt.Sprintf("%s%sproxy_set_header Upgrade $http_upgrade;\n", singleLocation, indentN2)
+			singleLocation = fmt.Sprintf("%s%sproxy_set_header Connection $connection_upgrade;\n", singleLocation, indentN2)
+			if readTimeout == "" {
+				readTimeout = websocketTimeout
+			}
+			if sendTimeout == "" {
+				sendTimeout = websocketTimeout
+			}
+		}
+		if proxy.ConnectTimeout != "" {
+			singleLocation = fmt.Sprintf("%s%sproxy_connect_timeout %s;\n", singleLocation, indentN2, proxy.ConnectTimeout)
+		}
+		if readTimeout != "" {
+			singleLocation = fmt.Sprintf("%s%sproxy_read_timeout %s;\n", singleLocation, indentN2, readTimeout)
+		}
+		if sendTimeout != "" {
+			singleLocation = fmt.Sprintf("%s%sproxy_send_timeout %s;\n", singleLocation, indentN2, sendTimeout)
+		}
+
+		singleLocation = fmt.Sprintf("%s%s}\n", singleLocation, indentN1)
+		sumLocations = sumLocations + singleLocation
+	}
+	return sumLocations
+}
@@ -0,0 +1,159 @@
+package nginx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const expectedNginxConfWithProxiesPartial = `
+	map $http_upgrade $connection_upgrade {
+		default upgrade;
+		'' close;
+	}
+	upstream radish_api {
+		server api-backend:8080;
+	}
+	upstream radish_auth {
+		server auth.example.svc:8443;
+	}
+	upstream radish_ws {
+		server 10.0.0.12:9000;
+	}
+
+	server {
+		listen 8080;
+
+		location /api {
+			proxy_pass http://radish_api;
+			proxy_http_version 1.1;
+			proxy_set_header Host api-backend:8080;
+		}
+		location /auth {
+			proxy_pass http://radish_auth;
+			proxy_http_version 1.1;
+			proxy_set_header Host auth.example.svc:8443;
+			proxy_connect_timeout 5s;
+			proxy_read_timeout 30s;
+		}
+		location /ws {
+			proxy_pass http://radish_ws;
+			proxy_http_version 1.1;
+			proxy_set_header Host 10.0.0.12:9000;
+			proxy_set_header Upgrade $http_upgrade;
+			proxy_set_header Connection $connection_upgrade;
+			proxy_read_timeout 3600s;
+			proxy_send_timeout 10m;
+		}
+
+		location /web/ {
+			root /u01/static;
+			try_files $uri /web/index.html;
+		}
+
+		location =/ {
+			if ($request_method = HEAD) {
+				return 200;
+			}
+			return 404 "Application is served under /web/";
+		}
+	}
+}
+`
+
+func TestGenerateNginxConfigurationWithProxies(t *testing.T) {
+	openshiftConfig, err := ReadOpenshiftConfig("testdata/testRadishConfigWithProxies.json")
+	assert.NoError(t, err)
+
+	var actual string
+	err = generateNginxConfiguration(openshiftConfig, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cleanString(nginxConfPrefix+expectedNginxConfWithProxiesPartial), cleanString(actual))
+
+	validateNginxConfig(t, actual)
+}
+
+func TestProxiesKeepTheAPILocationWhenItIsProxiedToNodejs(t *testing.T) {
+	openshiftConfig := OpenshiftConfig{
+		Web: Web{
+			Nodejs:  Nodejs{Main: "server.js"},
+			Proxies: []nginxProxy{{Path: "/auth", Host: "auth", Port: 8080}},
+		},
+	}
+
+	input, err := mapDataDescToTemplateInput(openshiftConfig)
+
+	assert.NoError(t, err)
+	assert.True(t, input.APILocation)
+	assert.Contains(t, input.ProxyLocations, "location /auth {")
+
+	openshiftConfig.Web.Proxies = []nginxProxy{{Path: "/api", Host: "api", Port: 8080}}
+	_, err = mapDataDescToTemplateInput(openshiftConfig)
+	assert.EqualError(t, err, "Proxy path /api is already a location")
+}
+
+func TestWebsocketProxiesUseNginxWebsocketTimeout(t *testing.T) {
+	t.Setenv("NGINX_WEBSOCKET_TIMEOUT", "2h")
+	openshiftConfig := OpenshiftConfig{
+		Web: Web{
+			Proxies: []nginxProxy{{Path: "/ws", Host: "ws", Port: 8080, Websocket: true}},
+		},
+	}
+
+	_, err := mapDataDescToTemplateInput(openshiftConfig)
+	assert.EqualError(t, err, `NGINX_WEBSOCKET_TIMEOUT "2h" should be on the form N, Nms, Ns or Nm`)
+
+	t.Setenv("NGINX_WEBSOCKET_TIMEOUT", "120m")
+	input, err := mapDataDescToTemplateInput(openshiftConfig)
+
+	assert.NoError(t, err)
+	assert.True(t, input.WebsocketMap)
+	assert.False(t, input.APIWebsocket)
+	assert.Contains(t, input.ProxyLocations, "proxy_read_timeout 120m;")
+	assert.Contains(t, input.ProxyLocations, "proxy_send_timeout 120m;")
+}
+
+func TestInvalidProxiesArePrevented(t *testing.T) {
+	tests := map[string]struct {
+		proxy nginxProxy
+		err   string
+	}{
+		"path": {
+			proxy: nginxProxy{Path: "api; return 200", Host: "api", Port: 8080},
+			err:   `Proxy path "api; return 200" should start with / and only contain letters, digits and . _ ~ / -`,
+		},
+		"webapp path": {
+			proxy: nginxProxy{Path: "/", Host: "api", Port: 8080},
+			err:   "Proxy path / is already a location",
+		},
+		"host": {
+			proxy: nginxProxy{Path: "/auth", Host: "auth:8080", Port: 8080},
+			err:   `Proxy /auth: host "auth:8080" should be a host name or an IP address`,
+		},
+		"port": {
+			proxy: nginxProxy{Path: "/auth", Host: "auth"},
+			err:   "Proxy /auth: port 0 should be between 1 and 65535",
+		},
+		"timeout": {
+			proxy: nginxProxy{Path: "/auth", Host: "auth", Port: 8080, ReadTimeout: "1h"},
+			err:   `Proxy /auth: readTimeout "1h" should be on the form N, Nms, Ns or Nm`,
+		},
+	}
+	for name, test := range tests {
+		_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: Web{Proxies: []nginxProxy{test.proxy}}})
+		assert.EqualError(t, err, test.err, name)
+	}
+
+	_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: Web{Proxies: []nginxProxy{
+		{Path: "/auth", Host: "auth", Port: 8080},
+		{Path: "/auth", Host: "other", Port: 8080},
+	}}})
+	assert.EqualError(t, err, "Proxy path /auth is already a location")
+}
+
+func TestUpstreamNamesAreUnique(t *testing.T) {
+	names := upstreamNames([]nginxProxy{{Path: "/"}, {Path: "/a-b/"}, {Path: "/a.b"}})
+
+	assert.Equal(t, []string{"radish_root", "radish_a_b", "radish_a_b_2"}, names)
+}
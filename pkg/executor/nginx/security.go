@@ -0,0 +1,191 @@
+package nginx
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// nginxSecurity : security headers from a preset. Csp directives replace the directives of the preset.
+type nginxSecurity struct {
+	Preset string              `json:"preset"`
+	Csp    map[string][]string `json:"csp"`
+}
+
+type securityHeader struct {
+	name  string
+	value string
+}
+
+type securityPreset struct {
+	headers []securityHeader
+	csp     map[string][]string
+}
+
+// securityPresets : none is the default, so configs without web.security get the headers they always had
+var securityPresets = map[string]securityPreset{
+	"strict": {
+		headers: []securityHeader{
+			{"Strict-Transport-Security", "max-age=63072000; includeSubDomains"},
+			{"X-Frame-Options", "DENY"},
+			{"X-Content-Type-Options", "nosniff"},
+			{"Referrer-Policy", "no-referrer"},
+		},
+		csp: map[string][]string{
+			"default-src":     {"'self'"},
+			"object-src":      {"'none'"},
+			"base-uri":        {"'self'"},
+			"form-action":     {"'self'"},
+			"frame-ancestors": {"'none'"},
+		},
+	},
+	"standard": {
+		headers: []securityHeader{
+			{"Strict-Transport-Security", "max-age=31536000"},
+			{"X-Frame-Options", "SAMEORIGIN"},
+			{"X-Content-Type-Options", "nosniff"},
+			{"Referrer-Policy", "strict-origin-when-cross-origin"},
+		},
+		csp: map[string][]string{
+			"default-src":     {"'self'"},
+			"img-src":         {"'self'", "data:"},
+			"style-src":       {"'self'", "'unsafe-inline'"},
+			"object-src":      {"'none'"},
+			"base-uri":        {"'self'"},
+			"frame-ancestors": {"'self'"},
+		},
+	},
+	"none": {},
+}
+
+// cspDirectives : the directives that can be set, in the order they are written
+var cspDirectives = []string{
+	"default-src", "script-src", "style-src", "img-src", "font-src", "connect-src", "media-src", "object-src",
+	"frame-src", "worker-src", "manifest-src", "child-src", "frame-ancestors", "form-action", "base-uri",
+	"upgrade-insecure-requests", "report-uri", "report-to",
+}
+
+var cspKeywords = map[string]bool{
+	"'self'": true, "'none'": true, "'unsafe-inline'": true, "'unsafe-eval'": true, "'unsafe-hashes'": true,
+	"'strict-dynamic'": true, "'report-sample'": true, "'wasm-unsafe-eval'": true,
+}
+
+var (
+	cspHashSource = regexp.MustCompile(`^'(nonce|sha256|sha384|sha512)-[A-Za-z0-9+/_=-]+'$`)
+	// cspSource : hosts, schemes and URLs. Quotes, ; and , would end the source, and nginx would expand $
+	cspSource = regexp.MustCompile(`^[^\s;,'"{}$\\]+$`)
+)
+
+// override : the security of a location. A location with a preset starts over from it, otherwise its csp directives
+// replace those of s
+func (s nginxSecurity) override(location *nginxSecurity) nginxSecurity {
+	if location == nil {
+		return s
+	}
+	if location.Preset != "" {
+		return *location
+	}
+	result := nginxSecurity{Preset: s.Preset, Csp: map[string][]string{}}
+	for directive, sources := range s.Csp {
+		result.Csp[directive] = sources
+	}
+	for directive, sources := range location.Csp {
+		result.Csp[directive] = sources
+	}
+	return result
+}
+
+func validateSecurity(security nginxSecurity) error {
+	if _, exists := securityPresets[security.Preset]; security.Preset != "" && !exists {
+		return errors.Errorf("Security preset %s is not supported. Use strict, standard or none", security.Preset)
+	}
+	for _, directive := range sortedDirectives(security.Csp) {
+		sources := security.Csp[directive]
+		if !isCspDirective(directive) {
+			return errors.Errorf("Content-Security-Policy directive %s is not supported", directive)
+		}
+		if len(sources) == 0 && directive != "upgrade-insecure-requests" {
+			return errors.Errorf("Content-Security-Policy directive %s needs at least one source", directive)
+		}
+		for _, source := range sources {
+			if strings.HasPrefix(source, "'") {
+				if !cspKeywords[source] && !cspHashSource.MatchString(source) {
+					return errors.Errorf("Content-Security-Policy %s: %s is not a known keyword", directive, source)
+				}
+			} else if !cspSource.MatchString(source) {
+				return errors.Errorf("Content-Security-Policy %s: %q should be a host, scheme or URL", directive, source)
+			}
+		}
+	}
+	return nil
+}
+
+func isCspDirective(directive string) bool {
+	for _, known := range cspDirectives {
+		if directive == known {
+			return true
+		}
+	}
+	return false
+}
+
+// contentSecurityPolicy : the directives of the preset, replaced by the csp directives, in the order of cspDirectives
+func contentSecurityPolicy(security nginxSecurity) string {
+	directives := map[string][]string{}
+	for directive, sources := range securityPresets[security.Preset].csp {
+		directives[directive] = sources
+	}
+	for directive, sources := range security.Csp {
+		directives[directive] = sources
+	}
+	var policy []string
+	for _, directive := range cspDirectives {
+		sources, exists := directives[directive]
+		if !exists {
+			continue
+		}
+		policy = append(policy, strings.TrimSpace(directive+" "+strings.Join(sources, " ")))
+	}
+	return strings.Join(policy, "; ")
+}
+
+/*
+nginx does not inherit add_header from the server when a location has its own, so the security headers are written in
+every static location. A header the location sets itself is not written twice. X-Frame-Options can not allow the
+sources in a frame-ancestors directive, so it is left out when frame-ancestors is set.
+*/
+func securityHeadersToString(security nginxSecurity, own map[string]string, indent string) string {
+	ownHeaders := map[string]bool{}
+	for name := range own {
+		ownHeaders[strings.ToLower(name)] = true
+	}
+	if _, exists := security.Csp["frame-ancestors"]; exists {
+		ownHeaders["x-frame-options"] = true
+	}
+	securityHeaders := append([]securityHeader{}, securityPresets[security.Preset].headers...)
+	if policy := contentSecurityPolicy(security); policy != "" {
+		securityHeaders = append(securityHeaders, securityHeader{"Content-Security-Policy", policy})
+	}
+
+	sumHeaders := ""
+	for _, header := range securityHeaders {
+		if ownHeaders[strings.ToLower(header.name)] {
+			continue
+		}
+		sumHeaders = fmt.Sprintf("%s%sadd_header %s \"%s\" always;\n", sumHeaders, indent, header.name, header.value)
+	}
+	return sumHeaders
+}
+
+// sortedDirectives : the csp directives of a config, used to report them in a stable order
+func sortedDirectives(csp map[string][]string) []string {
+	var directives []string
+	for directive := range csp {
+		directives = append(directives, directive)
+	}
+	sort.Strings(directives)
+	return directives
+}
@@ -0,0 +1,116 @@
+package nginx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const expectedNginxConfWithSecurityPartial = `
+	server {
+		listen 8080;
+
+		location /api {
+		return 404;
+		}
+
+		location /web/ {
+			root /u01/static;
+			try_files $uri /web/index.html;
+			add_header X-Frame-Options "SAMEORIGIN";
+			add_header Strict-Transport-Security "max-age=63072000; includeSubDomains" always;
+			add_header X-Content-Type-Options "nosniff" always;
+			add_header Referrer-Policy "no-referrer" always;
+			add_header Content-Security-Policy "default-src 'self'; script-src 'self' https://cdn.example.com; connect-src 'self' wss://example.com; object-src 'none'; frame-ancestors 'none'; form-action 'self'; base-uri 'self'" always;
+		}
+
+		location /web/embed.html {
+			root /u01/static;
+			add_header Cache-Control "no-cache";
+			add_header Strict-Transport-Security "max-age=63072000; includeSubDomains" always;
+			add_header X-Content-Type-Options "nosniff" always;
+			add_header Referrer-Policy "no-referrer" always;
+			add_header Content-Security-Policy "default-src 'self'; script-src 'self' https://cdn.example.com; connect-src 'self' wss://example.com; object-src 'none'; frame-ancestors https://portal.example.com; form-action 'self'; base-uri 'self'" always;
+		}
+		location /web/legacy.html {
+			root /u01/static;
+		}
+
+		location =/ {
+			if ($request_method = HEAD) {
+				return 200;
+			}
+			return 404 "Application is served under /web/";
+		}
+	}
+}
+`
+
+func TestGenerateNginxConfigurationWithSecurity(t *testing.T) {
+	openshiftConfig, err := ReadOpenshiftConfig("testdata/testRadishConfigWithSecurity.json")
+	assert.NoError(t, err)
+
+	var actual string
+	err = generateNginxConfiguration(openshiftConfig, testFileWriter(&actual))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cleanString(nginxConfPrefix+expectedNginxConfWithSecurityPartial), cleanString(actual))
+
+	validateNginxConfig(t, actual)
+}
+
+func TestStandardSecurityPreset(t *testing.T) {
+	headers := securityHeadersToString(nginxSecurity{Preset: "standard"}, nil, "")
+
+	assert.Equal(t, `add_header Strict-Transport-Security "max-age=31536000" always;
+add_header X-Frame-Options "SAMEORIGIN" always;
+add_header X-Content-Type-Options "nosniff" always;
+add_header Referrer-Policy "strict-origin-when-cross-origin" always;
+add_header Content-Security-Policy "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; object-src 'none'; frame-ancestors 'self'; base-uri 'self'" always;
+`, headers)
+
+	assert.Empty(t, securityHeadersToString(nginxSecurity{}, nil, ""))
+	assert.Equal(t, "add_header Content-Security-Policy \"upgrade-insecure-requests\" always;\n",
+		securityHeadersToString(nginxSecurity{Preset: "none", Csp: map[string][]string{"upgrade-insecure-requests": {}}}, nil, ""))
+}
+
+func TestInvalidSecurityIsPrevented(t *testing.T) {
+	tests := map[string]struct {
+		security nginxSecurity
+		err      string
+	}{
+		"preset": {
+			security: nginxSecurity{Preset: "paranoid"},
+			err:      "Security preset paranoid is not supported. Use strict, standard or none",
+		},
+		"directive": {
+			security: nginxSecurity{Csp: map[string][]string{"script": {"'self'"}}},
+			err:      "Content-Security-Policy directive script is not supported",
+		},
+		"no sources": {
+			security: nginxSecurity{Csp: map[string][]string{"script-src": {}}},
+			err:      "Content-Security-Policy directive script-src needs at least one source",
+		},
+		"keyword without quotes": {
+			security: nginxSecurity{Csp: map[string][]string{"script-src": {"'self"}}},
+			err:      "Content-Security-Policy script-src: 'self is not a known keyword",
+		},
+		"injection": {
+			security: nginxSecurity{Csp: map[string][]string{"img-src": {"data:; script-src *"}}},
+			err:      `Content-Security-Policy img-src: "data:; script-src *" should be a host, scheme or URL`,
+		},
+		"variable": {
+			security: nginxSecurity{Csp: map[string][]string{"img-src": {"$host"}}},
+			err:      `Content-Security-Policy img-src: "$host" should be a host, scheme or URL`,
+		},
+	}
+	for name, test := range tests {
+		_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: Web{Security: test.security}})
+		assert.EqualError(t, err, test.err, name)
+	}
+
+	_, err := mapDataDescToTemplateInput(OpenshiftConfig{Web: Web{Locations: nginxLocations{
+		"index.html": {Security: &nginxSecurity{Preset: "paranoid"}},
+	}}})
+	assert.EqualError(t, err, "Location index.html: Security preset paranoid is not supported. Use strict, standard or none")
+}
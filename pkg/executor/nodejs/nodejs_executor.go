@@ -2,11 +2,15 @@ package nodejs
 
 import (
 	"os/exec"
+
+	"github.com/skatteetaten/radish/pkg/executor"
 )
 
 // Executor :
 type Executor interface {
 	PrepareForNodeJSRun(mainJavaScriptFile string) *exec.Cmd
+	LoadExitCodeMapping(openshiftConfigPath string) error
+	HandleExit(exitCode int, pid int) int
 }
 
 type nodeJSExecutor struct {
@@ -14,11 +18,12 @@ type nodeJSExecutor struct {
 }
 
 type nodeJSExitHandler struct {
+	exitCodeMapping executor.ExitCodeMapping
 }
 
 // NewNodeJSExecutor :
 func NewNodeJSExecutor() Executor {
-	return nodeJSExecutor{
+	return &nodeJSExecutor{
 		nodeJSExitHandler{},
 	}
 }
@@ -27,3 +32,17 @@ func (m nodeJSExecutor) PrepareForNodeJSRun(mainJavaScriptFile string) *exec.Cmd
 	cmd := exec.Command("node", mainJavaScriptFile)
 	return cmd
 }
+
+// LoadExitCodeMapping : nodejs is configured in the same openshift config as nginx. openshiftConfigPath is optional.
+func (m *nodeJSExitHandler) LoadExitCodeMapping(openshiftConfigPath string) error {
+	mapping, err := executor.LoadExitCodeMapping(openshiftConfigPath)
+	if err != nil {
+		return err
+	}
+	m.exitCodeMapping = mapping
+	return nil
+}
+
+func (m *nodeJSExitHandler) HandleExit(exitCode int, pid int) int {
+	return m.exitCodeMapping.Rewrite(exitCode)
+}
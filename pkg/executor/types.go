@@ -21,10 +21,18 @@ type TemplateInput struct {
 	Static             string
 	SPA                bool
 	ExtraStaticHeaders map[string]string
+	SecurityHeaders    string
+	CachingLocations   string
 	Path               string
 	HasProxyPass       bool
 	ProxyPassHost      string
 	ProxyPassPort      string
+	APILocation        bool
+	APIWebsocket       bool
+	WebsocketMap       bool
+	WebsocketTimeout   string
+	Upstreams          string
+	ProxyLocations     string
 	Gzip               string
 	Exclude            []string
 	Locations          string
@@ -33,4 +41,5 @@ type TemplateInput struct {
 	ProxyReadTimeout   string
 	NotServingOnRoot   bool
 	LogToFile          bool
+	LogFormat          string
 }
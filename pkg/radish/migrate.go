@@ -0,0 +1,59 @@
+package radish
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/executor/java"
+	"github.com/skatteetaten/radish/pkg/schema"
+)
+
+// MigrateDescriptor : upgrades the Java descriptor to the current version, and prints it or writes it back to the file.
+// Without args the radish descriptor is located like runJava does.
+func MigrateDescriptor(args []string, inPlace bool, format string) {
+	radishDescriptor, err := locateRadishDescriptor(args)
+	if err != nil {
+		logrus.Fatalf("Unable to load descriptor %s", err)
+	}
+	switch schema.Format(format) {
+	case "", schema.JSON, schema.YAML, schema.TOML:
+	default:
+		logrus.Fatalf("Unknown format %q. Use %s, %s or %s", format, schema.JSON, schema.YAML, schema.TOML)
+	}
+	if err := migrateDescriptor(os.Stdout, radishDescriptor, inPlace, schema.Format(format)); err != nil {
+		logrus.Fatalf("Failed to migrate descriptor %s", err)
+	}
+}
+
+/*
+The radish descriptor is found by its file name, so a descriptor written in place must keep its format. A radish.json
+with YAML in it would be read, but not by anyone expecting JSON.
+*/
+func migrateDescriptor(w io.Writer, radishDescriptor string, inPlace bool, format schema.Format) error {
+	data, err := os.ReadFile(radishDescriptor)
+	if err != nil {
+		return errors.Wrapf(err, "Error reading %s", radishDescriptor)
+	}
+	current := schema.DetectFormat(radishDescriptor, data)
+	if inPlace && format != "" && format != current {
+		return errors.Errorf("%s is %s, and can not be converted to %s in place. Leave out --in-place, and write the output to a %s file", radishDescriptor, current, format, format)
+	}
+	migrated, err := java.MigrateDescriptor(radishDescriptor, data, format)
+	if err != nil {
+		return errors.Wrapf(err, "Error migrating %s", radishDescriptor)
+	}
+	if !inPlace {
+		_, err := w.Write(migrated)
+		return err
+	}
+	info, err := os.Stat(radishDescriptor)
+	if err != nil {
+		return errors.Wrapf(err, "Error reading %s", radishDescriptor)
+	}
+	if err := os.WriteFile(radishDescriptor, migrated, info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "Error writing %s", radishDescriptor)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package radish
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skatteetaten/radish/pkg/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+const unversionedDescriptor = `{
+  "Type": "JavaDescriptor",
+  "Data": {
+    "MainClass": "foo.bar.Main",
+    "JavaOptions": "-Dfoo=bar"
+  }
+}`
+
+func TestMigrateDescriptorInPlaceKeepsTheFormat(t *testing.T) {
+	radishDescriptor := filepath.Join(t.TempDir(), "radish.json")
+	assert.NoError(t, os.WriteFile(radishDescriptor, []byte(unversionedDescriptor), 0644))
+
+	err := migrateDescriptor(&bytes.Buffer{}, radishDescriptor, true, schema.YAML)
+	assert.EqualError(t, err, radishDescriptor+" is json, and can not be converted to yaml in place. Leave out --in-place, and write the output to a yaml file")
+	dat, err := os.ReadFile(radishDescriptor)
+	assert.NoError(t, err)
+	assert.Equal(t, unversionedDescriptor, string(dat))
+
+	assert.NoError(t, migrateDescriptor(&bytes.Buffer{}, radishDescriptor, true, schema.JSON))
+	dat, err = os.ReadFile(radishDescriptor)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Type": "JavaDescriptor", "Version": "1", "Data": {"MainClass": "foo.bar.Main", "JavaOptions": "-Dfoo=bar"}}`, string(dat))
+}
+
+func TestMigrateDescriptorConvertsTheOutput(t *testing.T) {
+	radishDescriptor := filepath.Join(t.TempDir(), "radish.json")
+	assert.NoError(t, os.WriteFile(radishDescriptor, []byte(unversionedDescriptor), 0644))
+
+	var out bytes.Buffer
+	assert.NoError(t, migrateDescriptor(&out, radishDescriptor, false, schema.YAML))
+	assert.Equal(t, `Type: JavaDescriptor
+Version: "1"
+Data:
+  MainClass: foo.bar.Main
+  JavaOptions: -Dfoo=bar
+`, out.String())
+}
@@ -2,25 +2,26 @@ package radish
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"os"
-	"os/signal"
+	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	logw "git.aurora.skead.no/apsi/logwriter/log"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/executor"
 	"github.com/skatteetaten/radish/pkg/executor/java"
 	"github.com/skatteetaten/radish/pkg/executor/nginx"
 	"github.com/skatteetaten/radish/pkg/executor/nodejs"
-	"github.com/skatteetaten/radish/pkg/reaper"
 	"github.com/skatteetaten/radish/pkg/signaler"
+	"github.com/skatteetaten/radish/pkg/supervisor"
 )
 
 // RunRadish :
@@ -35,27 +36,15 @@ func RunRadish(args []string) {
 		logrus.Fatalf("Unable to start app %s", err)
 	}
 	logrus.Infof("Starting java with %s", strings.Join(cmd.Args, " "))
-	err = cmd.Start()
-	if err != nil {
-		logrus.Fatalf("Error starting: %s", err)
-	}
-	reaper.Start()
-	signal.Ignore(syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL)
-	pid := cmd.Process.Pid
-	signaler.Start(cmd.Process, findGraceTime())
-	var wstatus syscall.WaitStatus
-	_, err = syscall.Wait4(pid, &wstatus, 0, nil)
-	if err != nil {
-		logrus.Error(err)
-	}
-	logrus.Infof("Exit code %d", wstatus.ExitStatus())
-	exitCode := e.HandleExit(wstatus.ExitStatus(), pid)
-	os.Exit(exitCode)
+	supervise(cmd, newSupervisor("Java", supervisor.WithExitHandler(e.HandleExit)))
 }
 
 // RunNodeJS :
-func RunNodeJS(mainJavaScriptFile string, logLocation string, logFilename string, logFileRotateSize int) {
+func RunNodeJS(mainJavaScriptFile string, openshiftConfigPath string, logLocation string, logFilename string, logFileRotateSize int) {
 	e := nodejs.NewNodeJSExecutor()
+	if err := e.LoadExitCodeMapping(openshiftConfigPath); err != nil {
+		logrus.Fatalf("Unable to load exit code mapping: %s", err)
+	}
 
 	cmd := e.PrepareForNodeJSRun(mainJavaScriptFile)
 
@@ -71,7 +60,9 @@ func RunNodeJS(mainJavaScriptFile string, logLocation string, logFilename string
 		logrus.Fatalf("Something wrong with stderr pipe: %s", pipeerr)
 	}
 
+	logsWritten := make(chan bool)
 	go func() {
+		defer close(logsWritten)
 		mergedPipeReader := io.MultiReader(stdoutPipe, stderrPipe)
 		mergedPipeScanner := bufio.NewScanner(mergedPipeReader)
 		warningsGiven := 0
@@ -87,65 +78,141 @@ func RunNodeJS(mainJavaScriptFile string, logLocation string, logFilename string
 
 	}()
 
-	err1 := cmd.Start()
-	if err1 != nil {
-		logrus.Fatalf("Unable to start nodeJS: %v", err1)
+	supervise(cmd, newSupervisor("NodeJS", supervisor.WithExitHandler(e.HandleExit), supervisor.WithBeforeExit(func(exitCode int) {
+		// The rest of the output must be written before radish exits
+		select {
+		case <-logsWritten:
+		case <-time.After(supervisor.DefaultDescendantsGracePeriod):
+			logrus.Warn("Timed out writing the last NodeJS output to the log")
+		}
+	})))
+}
+
+// RunNginx :
+func RunNginx(nginxConfigPath string, openshiftConfigPath string, rotateLogsAfterSize, checkRotateAfter int) {
+	e := nginx.NewNginxExecutor(rotateLogsAfterSize, checkRotateAfter, []string{"/u01/logs/nginx.access", "/u01/logs/nginx.log"})
+	if err := e.LoadExitCodeMapping(openshiftConfigPath); err != nil {
+		logrus.Fatalf("Unable to load exit code mapping: %s", err)
 	}
 
-	reaper.Start()
-	signal.Ignore(syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGUSR1)
+	cmd := e.PrepareForNginxRun(nginxConfigPath)
 
-	pid := cmd.Process.Pid
-	logrus.Infof("Started nodejs with pid=%d", pid)
+	supervise(cmd, newSupervisor("Nginx", supervisor.WithAfterStart(e.StartLogRotate), supervisor.WithExitHandler(e.HandleExit)))
+}
 
-	if err := cmd.Wait(); err != nil {
-		log.Fatal(err)
+// RunCommand : runs an arbitrary command with radish as PID 1, rewriting exit codes with the mapping
+func RunCommand(args []string, exitCodeMapping string) {
+	fromFlag, err := executor.ParseExitCodeMapping(exitCodeMapping)
+	if err != nil {
+		logrus.Fatalf("Unable to parse exit code mapping: %s", err)
 	}
+	fromEnv, err := executor.ResolveExitCodeMapping(executor.ExitCodeMapping{}, nil, os.LookupEnv)
+	if err != nil {
+		logrus.Fatalf("Unable to parse exit code mapping: %s", err)
+	}
+	mapping := fromEnv.Merge(fromFlag)
+	cmd := prepareCommand(args)
+	supervise(cmd, newSupervisor(filepath.Base(args[0]), supervisor.WithExitHandler(func(exitCode int, pid int) int {
+		return mapping.Rewrite(exitCode)
+	})))
+}
 
-	signaler.Start(cmd.Process, findGraceTime())
-
-	var wstatus syscall.WaitStatus
+// DryRunCommand : prints what run would execute, without starting it
+func DryRunCommand(args []string) {
+	dryRun(prepareCommand(args), optionalRadishDescriptor([]string{}))
+}
 
-	_, _ = syscall.Wait4(pid, &wstatus, 0, nil)
+func prepareCommand(args []string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
 
-	if wstatus.Exited() && wstatus.ExitStatus() == 0 {
-		logrus.Info("NodeJS exited successfully")
-	} else {
-		logrus.Info("NodeJS terminated with exit code %d ", wstatus.ExitStatus())
+func supervise(cmd *exec.Cmd, s *supervisor.Supervisor) {
+	exitCode, err := s.Run(cmd)
+	if err != nil {
+		logrus.Fatal(err)
 	}
-	os.Exit(wstatus.ExitStatus())
-
+	os.Exit(exitCode)
 }
 
-// RunNginx :
-func RunNginx(nginxConfigPath string, rotateLogsAfterSize, checkRotateAfter int) {
-	e := nginx.NewNginxExecutor(rotateLogsAfterSize, checkRotateAfter, []string{"/u01/logs/nginx.access", "/u01/logs/nginx.log"})
-
-	cmd := e.PrepareForNginxRun(nginxConfigPath)
+// newSupervisor : a supervisor configured from the environment
+func newSupervisor(name string, options ...supervisor.Option) *supervisor.Supervisor {
+	defaults := []supervisor.Option{
+		supervisor.WithForwardGracetime(findGraceTime()),
+		supervisor.WithSignalerOptions(signalerOptions()...),
+	}
+	if strings.ToUpper(os.Getenv("RADISH_PROCESS_GROUP")) == "TRUE" {
+		defaults = append(defaults, supervisor.WithProcessGroup())
+	}
+	return supervisor.New(name, append(defaults, options...)...)
+}
 
-	err := cmd.Start()
+// DryRunJava : prints what runJava would execute, without starting it
+func DryRunJava(args []string) {
+	e := java.NewJavaExecutor()
+	radishDescriptor, err := locateRadishDescriptor(args)
 	if err != nil {
-		logrus.Fatalf("Unable to start nginx: %v", err)
+		logrus.Fatalf("Unable to load descriptor %s", err)
+	}
+	cmd, err := e.BuildCmd(radishDescriptor)
+	if err != nil {
+		logrus.Fatalf("Unable to start app %s", err)
 	}
-	reaper.Start()
-	signal.Ignore(syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGUSR1)
-	pid := cmd.Process.Pid
+	dryRun(cmd, radishDescriptor)
+}
 
-	logrus.Infof("Started nginx with pid=%d", pid)
+// DryRunNodeJS : prints what runNodeJS would execute, without starting it
+func DryRunNodeJS(args []string, mainJavaScriptFile string) {
+	e := nodejs.NewNodeJSExecutor()
+	dryRun(e.PrepareForNodeJSRun(mainJavaScriptFile), optionalRadishDescriptor(args))
+}
 
-	e.StartLogRotate(pid)
-	signaler.Start(cmd.Process, findGraceTime())
+// DryRunNginx : prints what runNginx would execute, without starting it
+func DryRunNginx(args []string, nginxConfigPath string) {
+	e := nginx.NewNginxExecutor(0, 0, []string{})
+	dryRun(e.PrepareForNginxRun(nginxConfigPath), optionalRadishDescriptor(args))
+}
 
-	var wstatus syscall.WaitStatus
+func dryRun(cmd *exec.Cmd, radishDescriptor string) {
+	err := executor.DescribeCmd(os.Stdout, cmd, radishDescriptor)
+	if err != nil {
+		logrus.Fatalf("Failed to describe command %s", err)
+	}
+	os.Exit(0)
+}
 
-	_, _ = syscall.Wait4(pid, &wstatus, 0, nil)
+// optionalRadishDescriptor : nginx and nodejs are started without reading the descriptor, so it is only informational
+func optionalRadishDescriptor(args []string) string {
+	radishDescriptor, err := locateRadishDescriptor(args)
+	if err != nil {
+		logrus.Debugf("No radish descriptor: %s", err)
+		return ""
+	}
+	return radishDescriptor
+}
 
-	if wstatus.Exited() && wstatus.ExitStatus() == 0 {
-		logrus.Info("Nginx exited successfully")
-	} else {
-		logrus.Info("Nginx terminated with exit code %d ", wstatus.ExitStatus())
+func signalerOptions() []signaler.Option {
+	options := []signaler.Option{signaler.WithTerminationGracePeriod(findTerminationGracePeriod())}
+	if forwardSignals, exists := os.LookupEnv("RADISH_FORWARD_SIGNALS"); exists {
+		signals, err := signaler.ParseSignals(forwardSignals)
+		if err != nil {
+			logrus.Warnf("Could not parse RADISH_FORWARD_SIGNALS %s (%s). Using default signals", forwardSignals, err)
+		} else {
+			options = append(options, signaler.WithForwardedSignals(signals))
+		}
 	}
-	os.Exit(wstatus.ExitStatus())
+	if signalMap, exists := os.LookupEnv("RADISH_SIGNAL_MAP"); exists {
+		translations, err := signaler.ParseSignalMap(signalMap)
+		if err != nil {
+			logrus.Warnf("Could not parse RADISH_SIGNAL_MAP %s (%s). Signals are forwarded as received", signalMap, err)
+		} else {
+			options = append(options, signaler.WithSignalMap(translations))
+		}
+	}
+	return options
 }
 
 func findGraceTime() time.Duration {
@@ -162,6 +229,20 @@ func findGraceTime() time.Duration {
 	return time.Duration(int64(sf) * int64(time.Second))
 }
 
+func findTerminationGracePeriod() time.Duration {
+	gracePeriod := os.Getenv("RADISH_TERMINATION_GRACE_PERIOD")
+	if gracePeriod == "" {
+		return 0
+	}
+	gp, err := strconv.Atoi(gracePeriod)
+	if err != nil {
+		logrus.Warnf("Could not parse %s to an integer (%s). Radish will wait for the process to terminate", gracePeriod, err)
+		return 0
+	}
+
+	return time.Duration(int64(gp) * int64(time.Second))
+}
+
 // PrintRadishCP :
 func PrintRadishCP(args []string) {
 	e := java.NewJavaExecutor()
@@ -176,6 +257,31 @@ func PrintRadishCP(args []string) {
 	fmt.Print(cp)
 }
 
+// ExplainJava : prints the java command line runJava would use, and which modificators contributed to it
+func ExplainJava(args []string, output string) {
+	radishDescriptor, err := locateRadishDescriptor(args)
+	if err != nil {
+		logrus.Fatalf("Unable to load descriptor %s", err)
+	}
+	explanation, err := java.ExplainJava(radishDescriptor)
+	if err != nil {
+		logrus.Fatalf("Failed to explain java arguments %s", err)
+	}
+	switch output {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(explanation)
+	case "text":
+		err = explanation.WriteText(os.Stdout)
+	default:
+		logrus.Fatalf("Unknown output format %s. Use text or json", output)
+	}
+	if err != nil {
+		logrus.Fatalf("Failed to write explanation %s", err)
+	}
+}
+
 func locateRadishDescriptor(args []string) (string, error) {
 	if len(args) > 0 {
 		_, err := os.Stat(args[0])
@@ -189,15 +295,20 @@ func locateRadishDescriptor(args []string) (string, error) {
 	if exists {
 		return descriptor, nil
 	}
-	if _, err := os.Stat("/u01/app/radish.json"); err == nil {
-		return "/u01/app/radish.json", nil
-	}
-	if _, err := os.Stat("/radish.json"); err == nil {
-		return "/radish.json", nil
+	for _, dir := range []string{"/u01/app", "/"} {
+		for _, name := range descriptorNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
 	}
 	return "", errors.New("No radish descriptor found")
 }
 
+// descriptorNames : the file names searched for in /u01/app and /, in order
+var descriptorNames = []string{"radish.json", "radish.yaml", "radish.yml", "radish.toml"}
+
 // GenerateNginxConfiguration :
 func GenerateNginxConfiguration(openshiftConfigPath string, nginxPath string) error {
 	return nginx.GenerateNginxConfiguration(openshiftConfigPath, nginxPath)
@@ -0,0 +1,105 @@
+package radish
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/executor/java"
+	"github.com/skatteetaten/radish/pkg/executor/nginx"
+	"github.com/skatteetaten/radish/pkg/schema"
+)
+
+const (
+	javaDescriptorType = "java"
+	webDescriptorType  = "web"
+)
+
+// ValidateDescriptor : validates the descriptors, and exits with 1 if any of them has problems.
+// Without args the radish descriptor is located like runJava does.
+func ValidateDescriptor(args []string, descriptorType string) {
+	files := args
+	if len(files) == 0 {
+		radishDescriptor, err := locateRadishDescriptor(args)
+		if err != nil {
+			logrus.Fatalf("Unable to load descriptor %s", err)
+		}
+		files = []string{radishDescriptor}
+	}
+	valid, err := validateDescriptors(os.Stdout, files, descriptorType)
+	if err != nil {
+		logrus.Fatalf("Failed to validate descriptor %s", err)
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// PrintDescriptorSchema : prints the JSON Schema of a descriptor type
+func PrintDescriptorSchema(descriptorType string) {
+	var descriptorSchema map[string]interface{}
+	switch descriptorType {
+	case javaDescriptorType:
+		descriptorSchema = java.DescriptorSchema()
+	case webDescriptorType:
+		descriptorSchema = nginx.OpenshiftConfigSchema()
+	default:
+		logrus.Fatalf("Unknown descriptor type %q. Use %s or %s", descriptorType, javaDescriptorType, webDescriptorType)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(descriptorSchema); err != nil {
+		logrus.Fatalf("Failed to write schema %s", err)
+	}
+}
+
+// validateDescriptors : writes file:line:column: problem for each problem, and returns false if there were any
+func validateDescriptors(w io.Writer, files []string, descriptorType string) (bool, error) {
+	valid := true
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return false, errors.Wrapf(err, "Error reading %s", file)
+		}
+		fileType := descriptorType
+		if fileType == "" {
+			fileType = detectDescriptorType(file, data)
+		}
+		var problems []schema.Problem
+		switch fileType {
+		case javaDescriptorType:
+			problems = java.ValidateDescriptor(file, data)
+		case webDescriptorType:
+			problems = nginx.ValidateOpenshiftConfig(file, data)
+		default:
+			return false, errors.Errorf("Unknown descriptor type %q. Use %s or %s", fileType, javaDescriptorType, webDescriptorType)
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(w, "%s:%s\n", file, problem)
+		}
+		if len(problems) > 0 {
+			valid = false
+		}
+	}
+	return valid, nil
+}
+
+// detectDescriptorType : the radish config for nginx and nodejs has docker and web at the top, the Java descriptor
+// has Type and Data
+func detectDescriptorType(file string, data []byte) string {
+	var top map[string]json.RawMessage
+	document, err := schema.Parse(file, data)
+	if err != nil || document.Decode(&top) != nil {
+		return javaDescriptorType
+	}
+	for key := range top {
+		if strings.EqualFold(key, "web") || strings.EqualFold(key, "docker") {
+			return webDescriptorType
+		}
+	}
+	return javaDescriptorType
+}
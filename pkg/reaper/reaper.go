@@ -1,30 +1,99 @@
 package reaper
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
-// Start : start the reaping of child processes.
+var (
+	started sync.Once
+	// mu is held while reaping, and while starting a watched process, so a process is never reaped before it is watched
+	mu      sync.Mutex
+	watched = make(map[int]chan syscall.WaitStatus)
+)
+
+// Start : start the reaping of child processes. Only the first call has any effect.
 func Start() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, syscall.SIGCHLD)
-	go reapChildren(c)
+	started.Do(func() {
+		if err := becomeSubreaper(); err != nil {
+			logrus.Warnf("Could not become child subreaper. Orphaned descendants will not be reaped by radish: %s", err)
+		}
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGCHLD)
+		go reapChildren(c)
+		// Children that exited before we were notified
+		reapAll()
+	})
+}
+
+// StartProcess : starts cmd, and returns a channel where its wait status is sent when it is reaped.
+// Processes started with cmd.Start() instead may be reaped without their status being handed back.
+func StartProcess(cmd *exec.Cmd) (<-chan syscall.WaitStatus, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	status := make(chan syscall.WaitStatus, 1)
+	watched[cmd.Process.Pid] = status
+	return status, nil
 }
 
 func reapChildren(signals chan os.Signal) {
 	for range signals {
-		for {
-			var status syscall.WaitStatus
-			pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
-			if err == nil {
-				logrus.Infof("Reaped process %d with exit status %d", pid, status)
-			} else {
-				break
-			}
+		reapAll()
+	}
+}
+
+func reapAll() {
+	for {
+		pid, err := reap()
+		if err != nil || pid <= 0 {
+			return
+		}
+	}
+}
+
+// reap : reaps one exited child, if any. Returns ECHILD when there are no children.
+func reap() (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	var status syscall.WaitStatus
+	pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+	if err != nil || pid <= 0 {
+		return pid, err
+	}
+	if exitStatus, exists := watched[pid]; exists {
+		delete(watched, pid)
+		exitStatus <- status
+		return pid, nil
+	}
+	logrus.Infof("Reaped process %d with exit status %d", pid, status)
+	return pid, nil
+}
+
+// Drain : reaps remaining descendants until there are none left, or the timeout expires.
+// Returns false if there are still descendants running.
+func Drain(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		pid, err := reap()
+		if errors.Is(err, syscall.ECHILD) {
+			return true
+		}
+		if err == nil && pid > 0 {
+			continue
+		}
+		if time.Now().After(deadline) {
+			return false
 		}
+		time.Sleep(50 * time.Millisecond)
 	}
 }
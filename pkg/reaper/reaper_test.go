@@ -0,0 +1,58 @@
+package reaper
+
+import (
+	"bufio"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainReapsExitedChildren(t *testing.T) {
+	cmd := exec.Command("true")
+	assert.NoError(t, cmd.Start())
+
+	assert.True(t, Drain(2*time.Second))
+	assert.ErrorIs(t, syscall.Kill(cmd.Process.Pid, 0), syscall.ESRCH)
+}
+
+func TestDrainTimesOutWhileChildrenAreRunning(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	assert.NoError(t, cmd.Start())
+
+	assert.False(t, Drain(100*time.Millisecond))
+
+	assert.NoError(t, cmd.Process.Kill())
+	assert.True(t, Drain(2*time.Second))
+}
+
+func TestDrainReapsOrphanedGrandchildren(t *testing.T) {
+	assert.NoError(t, becomeSubreaper())
+
+	cmd := exec.Command("sh", "-c", "sleep 0.2 & echo $!")
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	assert.NoError(t, err)
+	grandchild, err := strconv.Atoi(strings.TrimSpace(line))
+	assert.NoError(t, err)
+
+	assert.True(t, Drain(2*time.Second))
+	assert.ErrorIs(t, syscall.Kill(grandchild, 0), syscall.ESRCH)
+}
+
+func TestStartProcessHandsBackExitStatus(t *testing.T) {
+	Start()
+	for i := 0; i < 50; i++ {
+		exitStatus, err := StartProcess(exec.Command("sh", "-c", "exit 7"))
+		assert.NoError(t, err)
+
+		status := <-exitStatus
+		assert.Equal(t, 7, status.ExitStatus())
+	}
+}
@@ -0,0 +1,9 @@
+package reaper
+
+import "golang.org/x/sys/unix"
+
+// becomeSubreaper : orphaned descendants are reparented to radish instead of to PID 1,
+// so they can be reaped when radish is not PID 1 in the container
+func becomeSubreaper() error {
+	return unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package reaper
+
+func becomeSubreaper() error {
+	return nil
+}
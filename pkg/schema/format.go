@@ -0,0 +1,438 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Format : the file format of a descriptor
+type Format string
+
+// Supported formats
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	TOML Format = "toml"
+)
+
+// tomlStart : a table header or a key = value pair. YAML uses key: value.
+var tomlStart = regexp.MustCompile(`^(\[[^\]]+\]\]?|[A-Za-z0-9_."'-]+\s*=)`)
+
+// DetectFormat : the format from the file extension, or from the content when the extension is unknown
+func DetectFormat(filename string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return JSON
+	case ".yaml", ".yml":
+		return YAML
+	case ".toml":
+		return TOML
+	}
+	for _, line := range strings.Split(string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return JSON
+		}
+		if tomlStart.MatchString(line) {
+			return TOML
+		}
+		return YAML
+	}
+	return JSON
+}
+
+// Document : a descriptor converted to JSON, so it can be decoded and validated like a JSON descriptor
+type Document struct {
+	Format Format
+	JSON   []byte
+	// segments maps positions in JSON back to the original document, per line in JSON
+	segments map[int][]segment
+}
+
+type segment struct {
+	column int
+	line   int
+	source int
+}
+
+// Parse : converts a JSON, YAML or TOML descriptor to JSON. The format is detected with DetectFormat.
+func Parse(filename string, data []byte) (*Document, error) {
+	format := DetectFormat(filename, data)
+	switch format {
+	case YAML:
+		return parseYAML(data)
+	case TOML:
+		return parseTOML(data)
+	default:
+		return &Document{Format: JSON, JSON: data}, nil
+	}
+}
+
+// Decode : decodes the document into v, like encoding/json
+func (d *Document) Decode(v interface{}) error {
+	return json.NewDecoder(bytes.NewReader(d.JSON)).Decode(v)
+}
+
+// Validate : like Validate, with the positions in the original document. TOML has no positions, so only
+// the path is reported for TOML.
+func (d *Document) Validate(v interface{}) []Problem {
+	problems := Validate(d.JSON, v)
+	if d.Format == JSON {
+		return problems
+	}
+	for i, problem := range problems {
+		problems[i].Line, problems[i].Column = 0, 0
+		candidates := d.segments[problem.Line]
+		for _, candidate := range candidates {
+			if candidate.column <= problem.Column {
+				problems[i].Line, problems[i].Column = candidate.line, candidate.source
+			}
+		}
+	}
+	return problems
+}
+
+// ValidateFile : parses and validates a descriptor. Parse errors are returned as a problem.
+func ValidateFile(filename string, data []byte, v interface{}) []Problem {
+	document, err := Parse(filename, data)
+	if err != nil {
+		return []Problem{parseProblem(err, data)}
+	}
+	return document.Validate(v)
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
+func parseProblem(err error, data []byte) Problem {
+	var tomlError toml.ParseError
+	if errors.As(err, &tomlError) {
+		start := tomlError.Position.Start
+		if start > len(data) {
+			start = len(data)
+		}
+		message := tomlError.Message
+		if message == "" {
+			message = tomlError.Error()
+		}
+		return Problem{Line: tomlError.Position.Line, Column: start - bytes.LastIndexByte(data[:start], '\n'), Message: message}
+	}
+	message := strings.TrimPrefix(errors.Cause(err).Error(), "yaml: ")
+	if match := yamlLine.FindStringSubmatch(message); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Problem{Line: line, Column: 1, Message: strings.Replace(message, match[0], "", 1)}
+	}
+	return Problem{Message: message}
+}
+
+func parseYAML(data []byte) (*Document, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, errors.Wrap(err, "Error parsing YAML")
+	}
+	w := &jsonWriter{segments: map[int][]segment{}, line: 1}
+	if len(root.Content) == 0 {
+		w.WriteString("null")
+	} else if err := w.yamlNode(root.Content[0], 0); err != nil {
+		return nil, err
+	}
+	return &Document{Format: YAML, JSON: w.Bytes(), segments: w.segments}, nil
+}
+
+func parseTOML(data []byte) (*Document, error) {
+	var values map[string]interface{}
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, errors.Wrap(err, "Error parsing TOML")
+	}
+	dat, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Format: TOML, JSON: dat}, nil
+}
+
+// jsonWriter : writes JSON with one value per line, and remembers where each key and value came from
+type jsonWriter struct {
+	bytes.Buffer
+	segments map[int][]segment
+	line     int
+	column   int
+}
+
+func (w *jsonWriter) write(s string) {
+	w.WriteString(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		w.line += strings.Count(s, "\n")
+		w.column = len(s) - i - 1
+	} else {
+		w.column += len(s)
+	}
+}
+
+func (w *jsonWriter) mark(node *yaml.Node) {
+	w.segments[w.line] = append(w.segments[w.line], segment{column: w.column + 1, line: node.Line, source: node.Column})
+}
+
+func (w *jsonWriter) newline(indent int) {
+	w.write("\n" + strings.Repeat("  ", indent))
+}
+
+func (w *jsonWriter) yamlNode(node *yaml.Node, indent int) error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	w.mark(node)
+	switch node.Kind {
+	case yaml.MappingNode:
+		w.write("{")
+		pairs := node.Content
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if i > 0 {
+				w.write(",")
+			}
+			w.newline(indent + 1)
+			w.mark(pairs[i])
+			key, _ := json.Marshal(pairs[i].Value)
+			w.write(string(key) + ": ")
+			if err := w.yamlNode(pairs[i+1], indent+1); err != nil {
+				return err
+			}
+		}
+		if len(pairs) > 0 {
+			w.newline(indent)
+		}
+		w.write("}")
+	case yaml.SequenceNode:
+		w.write("[")
+		for i, item := range node.Content {
+			if i > 0 {
+				w.write(",")
+			}
+			w.newline(indent + 1)
+			if err := w.yamlNode(item, indent+1); err != nil {
+				return err
+			}
+		}
+		if len(node.Content) > 0 {
+			w.newline(indent)
+		}
+		w.write("]")
+	case yaml.ScalarNode:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return errors.Wrapf(err, "Error parsing YAML at line %d", node.Line)
+		}
+		dat, err := json.Marshal(jsonCompatible(value))
+		if err != nil {
+			return errors.Wrapf(err, "Error converting YAML at line %d", node.Line)
+		}
+		w.write(string(dat))
+	default:
+		return fmt.Errorf("Unsupported YAML at line %d", node.Line)
+	}
+	return nil
+}
+
+// jsonCompatible : YAML timestamps and binary values are written as strings
+func jsonCompatible(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, bool, string, int, int64, uint64, float64:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// Encode : converts a JSON document to format. Null values, empty strings, empty arrays and empty objects are
+// left out. Fields keep their order in JSON and YAML, TOML sorts them.
+func Encode(data []byte, format Format) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeOrdered(decoder)
+	if err != nil {
+		return nil, err
+	}
+	value = prune(value)
+	var out bytes.Buffer
+	switch format {
+	case YAML:
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(toYAMLNode(value)); err != nil {
+			return nil, err
+		}
+		err = encoder.Close()
+	case TOML:
+		err = toml.NewEncoder(&out).Encode(toPlain(value))
+	default:
+		var dat []byte
+		dat, err = json.MarshalIndent(value, "", "  ")
+		out.Write(append(dat, '\n'))
+	}
+	return out.Bytes(), err
+}
+
+// orderedObject : a JSON object that remembers the order of its keys
+type orderedObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(k)
+		out.WriteByte(':')
+		out.Write(v)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := &orderedObject{values: map[string]interface{}{}}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := object.values[key.(string)]; !exists {
+				object.keys = append(object.keys, key.(string))
+			}
+			object.values[key.(string)] = value
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeOrdered(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// prune : removes null values, empty strings, empty arrays and empty objects. Returns nil if nothing is left.
+func prune(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *orderedObject:
+		pruned := &orderedObject{values: map[string]interface{}{}}
+		for _, key := range value.keys {
+			if v := prune(value.values[key]); v != nil {
+				pruned.keys = append(pruned.keys, key)
+				pruned.values[key] = v
+			}
+		}
+		if len(pruned.keys) == 0 {
+			return nil
+		}
+		return pruned
+	case []interface{}:
+		pruned := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			if v := prune(item); v != nil {
+				pruned = append(pruned, v)
+			}
+		}
+		if len(pruned) == 0 {
+			return nil
+		}
+		return pruned
+	case string:
+		if value == "" {
+			return nil
+		}
+	}
+	return value
+}
+
+func toYAMLNode(value interface{}) *yaml.Node {
+	switch value := value.(type) {
+	case *orderedObject:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range value.keys {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, toYAMLNode(value.values[key]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range value {
+			node.Content = append(node.Content, toYAMLNode(item))
+		}
+		return node
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: value.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(value)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(value)}
+	}
+}
+
+// toPlain : maps, slices and numbers the TOML encoder understands
+func toPlain(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *orderedObject:
+		plain := make(map[string]interface{}, len(value.keys))
+		for _, key := range value.keys {
+			plain[key] = toPlain(value.values[key])
+		}
+		return plain
+	case []interface{}:
+		plain := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			plain = append(plain, toPlain(item))
+		}
+		return plain
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	default:
+		return value
+	}
+}
@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, YAML, DetectFormat("radish.yml", []byte("{}")))
+	assert.Equal(t, YAML, DetectFormat("radish.YAML", []byte("")))
+	assert.Equal(t, TOML, DetectFormat("radish.toml", []byte("")))
+	assert.Equal(t, JSON, DetectFormat("radish.json", []byte("a: b")))
+
+	assert.Equal(t, JSON, DetectFormat("", []byte("\n  {\"a\": 1}")))
+	assert.Equal(t, YAML, DetectFormat("", []byte("# comment\nType: JavaDescriptor\n")))
+	assert.Equal(t, YAML, DetectFormat("", []byte("---\nType: JavaDescriptor\n")))
+	assert.Equal(t, TOML, DetectFormat("", []byte("# comment\nType = \"JavaDescriptor\"\n")))
+	assert.Equal(t, TOML, DetectFormat("", []byte("[web.webapp]\ncontent = \"build\"\n")))
+}
+
+func TestParseYAML(t *testing.T) {
+	document, err := Parse("radish.yaml", []byte(`
+Name: yaml
+Count: 3
+Paths:
+  - a
+  - b
+Inner:
+  enabled: true
+  headers:
+    X-Any: &value shared
+    X-Other: *value
+`))
+	assert.NoError(t, err)
+
+	var decoded testDocument
+	assert.NoError(t, document.Decode(&decoded))
+	assert.Equal(t, "yaml", decoded.Name)
+	assert.Equal(t, 3, decoded.Count)
+	assert.Equal(t, []string{"a", "b"}, decoded.Paths)
+	assert.Equal(t, map[string]string{"X-Any": "shared", "X-Other": "shared"}, decoded.Inner.Headers)
+	assert.Empty(t, document.Validate(testDocument{}))
+}
+
+func TestValidateYAMLReportsOriginalPositions(t *testing.T) {
+	problems := ValidateFile("radish.yml", []byte(`Name: yaml
+Inner:
+  enabeld: true
+  headers:
+    a: [1]
+Count: many
+`), testDocument{})
+
+	assert.Equal(t, []string{
+		`3:3: Inner: unknown field "enabeld", did you mean "enabled"?`,
+		`5:8: Inner.headers.a: expected string, got array`,
+		`6:8: Count: expected integer, got string`,
+	}, problemStrings(problems))
+}
+
+func TestParseTOML(t *testing.T) {
+	document, err := Parse("radish.toml", []byte(`
+Name = "toml"
+Count = 3
+Paths = ["a", "b"]
+
+[Inner]
+enabled = true
+unknown = 1
+`))
+	assert.NoError(t, err)
+
+	var decoded testDocument
+	assert.NoError(t, document.Decode(&decoded))
+	assert.Equal(t, "toml", decoded.Name)
+	assert.True(t, decoded.Inner.Enabled)
+	assert.Equal(t, []string{`Inner: unknown field "unknown"`}, problemStrings(document.Validate(testDocument{})))
+}
+
+func TestValidateFileReportsParseErrors(t *testing.T) {
+	problems := ValidateFile("radish.yaml", []byte("Name: yaml\n  Count: 3\n"), testDocument{})
+	assert.Len(t, problems, 1)
+	assert.Equal(t, 2, problems[0].Line)
+
+	problems = ValidateFile("radish.toml", []byte("Name = \"toml\"\nCount = = 3\n"), testDocument{})
+	assert.Len(t, problems, 1)
+	assert.Equal(t, 2, problems[0].Line)
+}
+
+func TestEncodeKeepsOrderAndLeavesOutEmptyValues(t *testing.T) {
+	data := []byte(`{"Name": "x", "Empty": "", "Paths": [], "Inner": {"enabled": true, "headers": {}}, "Count": 3, "Nil": null}`)
+
+	encoded, err := Encode(data, JSON)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"Name\": \"x\",\n  \"Inner\": {\n    \"enabled\": true\n  },\n  \"Count\": 3\n}\n", string(encoded))
+
+	encoded, err = Encode(data, YAML)
+	assert.NoError(t, err)
+	assert.Equal(t, "Name: x\nInner:\n  enabled: true\nCount: 3\n", string(encoded))
+
+	encoded, err = Encode(data, TOML)
+	assert.NoError(t, err)
+	document, err := Parse("radish.toml", encoded)
+	assert.NoError(t, err)
+	var decoded testDocument
+	assert.NoError(t, document.Decode(&decoded))
+	assert.Equal(t, "x", decoded.Name)
+	assert.Equal(t, 3, decoded.Count)
+	assert.True(t, decoded.Inner.Enabled)
+}
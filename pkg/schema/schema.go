@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Generate : a JSON Schema for the documents encoding/json can decode into v. Unknown fields are not allowed.
+// Field names are matched case-insensitively, like Validate and encoding/json do. properties has the spelling in Go,
+// so editors suggest it, and patternProperties accepts the other spellings.
+func Generate(v interface{}, title string) map[string]interface{} {
+	result := typeSchema(reflect.TypeOf(v))
+	result["$schema"] = draft
+	result["title"] = title
+	return result
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		patternProperties := make(map[string]interface{})
+		for name, field := range fields(t) {
+			fieldSchema := typeSchema(field.Type)
+			properties[name] = fieldSchema
+			patternProperties[caseInsensitivePattern(name)] = fieldSchema
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"patternProperties":    patternProperties,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem()),
+		}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	default:
+		return map[string]interface{}{"type": typeName(t)}
+	}
+}
+
+// caseInsensitivePattern : JSON Schema patterns have no case-insensitive flag, so every letter is a class of both cases
+func caseInsensitivePattern(name string) string {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range name {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower == upper {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		} else {
+			pattern.WriteString("[" + string(upper) + string(lower) + "]")
+		}
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
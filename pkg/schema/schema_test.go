@@ -0,0 +1,31 @@
+package schema
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaExample struct {
+	MainClass string
+	UseStatic string `json:"use_static"`
+}
+
+func TestGeneratedSchemaMatchesFieldNamesLikeValidate(t *testing.T) {
+	generated := Generate(schemaExample{}, "Example")
+
+	assert.Contains(t, generated["properties"], "MainClass")
+	patterns := generated["patternProperties"].(map[string]interface{})
+	assert.Len(t, patterns, 2)
+	assert.Contains(t, patterns, "^[Mm][Aa][Ii][Nn][Cc][Ll][Aa][Ss][Ss]$")
+	assert.Contains(t, patterns, "^[Uu][Ss][Ee]_[Ss][Tt][Aa][Tt][Ii][Cc]$")
+
+	mainClass := regexp.MustCompile("^[Mm][Aa][Ii][Nn][Cc][Ll][Aa][Ss][Ss]$")
+	for _, key := range []string{"MainClass", "mainclass", "MAINCLASS", "mainClass"} {
+		assert.True(t, mainClass.MatchString(key), key)
+		assert.Empty(t, Validate([]byte(`{"`+key+`": "foo.bar.Main"}`), schemaExample{}), key)
+	}
+	assert.False(t, mainClass.MatchString("main_class"))
+	assert.NotEmpty(t, Validate([]byte(`{"main_class": "foo.bar.Main"}`), schemaExample{}))
+}
@@ -0,0 +1,345 @@
+package schema
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// StrictEnv : set to false to log problems in a descriptor or config as warnings, instead of failing radish
+const StrictEnv = "RADISH_STRICT_DESCRIPTOR"
+
+// Problem : something in a descriptor that does not match the Go type it is decoded into
+type Problem struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return strings.TrimPrefix(p.Path+": "+p.Message, ": ")
+	}
+	if p.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Path, p.Message)
+}
+
+// Report : fails with the problems, so a typo does not start a broken image. When RADISH_STRICT_DESCRIPTOR is false
+// they are logged as warnings instead, like they were before radish validated descriptors.
+func Report(what string, problems []Problem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	if strings.ToUpper(os.Getenv(StrictEnv)) == "FALSE" {
+		for _, problem := range problems {
+			logrus.Warnf("%s %s", what, problem)
+		}
+		return nil
+	}
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	return errors.Errorf("%s has problems: %s. Set %s=false to only log them", what, strings.Join(messages, ", "), StrictEnv)
+}
+
+// Validate : decodes data like encoding/json would decode it into v, and reports unknown fields, type mismatches
+// and syntax errors with the line and column they were found at. Field names are matched case-insensitively,
+// like encoding/json does. v is not modified.
+func Validate(data []byte, v interface{}) []Problem {
+	w := &walker{data: data, decoder: json.NewDecoder(bytes.NewReader(data)), problems: []Problem{}}
+	w.decoder.UseNumber()
+	if err := w.value(reflect.TypeOf(v), ""); err != nil {
+		w.syntaxError(err)
+		return w.problems
+	}
+	if _, err := w.decoder.Token(); err != io.EOF {
+		w.report(int(w.decoder.InputOffset()), "", "unexpected data after the end of the document")
+	}
+	return w.problems
+}
+
+type walker struct {
+	data     []byte
+	decoder  *json.Decoder
+	problems []Problem
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// value : reads the next value, and checks it against t
+func (w *walker) value(t reflect.Type, path string) error {
+	offset := w.nextOffset()
+	token, err := w.decoder.Token()
+	if err != nil {
+		return err
+	}
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() == reflect.Interface {
+		return w.skip(token)
+	}
+
+	switch token := token.(type) {
+	case json.Delim:
+		switch {
+		case token == '{' && t.Kind() == reflect.Struct:
+			return w.object(path, func(key string, offset int) (reflect.Type, string) {
+				field, found := findField(t, key)
+				if !found {
+					w.report(offset, path, fmt.Sprintf("unknown field %q%s", key, suggest(key, fieldNames(t))))
+					return nil, ""
+				}
+				return field.Type, join(path, key)
+			})
+		case token == '{' && t.Kind() == reflect.Map:
+			return w.object(path, func(key string, _ int) (reflect.Type, string) {
+				return t.Elem(), join(path, key)
+			})
+		case token == '[' && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+			for w.decoder.More() {
+				if err := w.value(t.Elem(), path+"[]"); err != nil {
+					return err
+				}
+			}
+			_, err := w.decoder.Token()
+			return err
+		}
+		w.mismatch(offset, path, t, map[json.Delim]string{'{': "object", '[': "array"}[token])
+		return w.skip(token)
+	case string:
+		if t.Kind() != reflect.String && !reflect.PtrTo(t).Implements(textUnmarshalerType) {
+			w.mismatch(offset, path, t, "string")
+		}
+	case json.Number:
+		w.number(offset, path, t, token)
+	case bool:
+		if t.Kind() != reflect.Bool {
+			w.mismatch(offset, path, t, "boolean")
+		}
+	}
+	return nil
+}
+
+// object : reads the keys and values of an object. field returns the type of the value of a key, or nil to skip it
+func (w *walker) object(path string, field func(key string, offset int) (reflect.Type, string)) error {
+	for w.decoder.More() {
+		offset := w.nextOffset()
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		t, fieldPath := field(token.(string), offset)
+		if t == nil {
+			token, err := w.decoder.Token()
+			if err != nil {
+				return err
+			}
+			if err := w.skip(token); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.value(t, fieldPath); err != nil {
+			return err
+		}
+	}
+	_, err := w.decoder.Token()
+	return err
+}
+
+func (w *walker) number(offset int, path string, t reflect.Type, number json.Number) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := number.Int64(); err != nil {
+			w.report(offset, path, fmt.Sprintf("expected an integer, got %s", number))
+		}
+	case reflect.Float32, reflect.Float64:
+	default:
+		w.mismatch(offset, path, t, "number")
+	}
+}
+
+// skip : skips the rest of a value that starts with token
+func (w *walker) skip(token json.Token) error {
+	if token != json.Delim('{') && token != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+// nextOffset : the offset of the next token. The decoder only knows where the previous one ended.
+func (w *walker) nextOffset() int {
+	offset := int(w.decoder.InputOffset())
+	for offset < len(w.data) && strings.ContainsRune(" \t\r\n,:", rune(w.data[offset])) {
+		offset++
+	}
+	return offset
+}
+
+func (w *walker) mismatch(offset int, path string, t reflect.Type, got string) {
+	w.report(offset, path, fmt.Sprintf("expected %s, got %s", typeName(t), got))
+}
+
+func (w *walker) syntaxError(err error) {
+	offset := int(w.decoder.InputOffset())
+	if syntaxError, ok := err.(*json.SyntaxError); ok {
+		offset = int(syntaxError.Offset)
+	}
+	// The offset is where the decoder stopped, which may be before the whitespace in front of the error
+	for offset < len(w.data) && strings.ContainsRune(" \t\r\n", rune(w.data[offset])) {
+		offset++
+	}
+	message := err.Error()
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		message = "unexpected end of JSON input"
+	}
+	w.report(offset, "", message)
+}
+
+func (w *walker) report(offset int, path string, message string) {
+	if offset > len(w.data) {
+		offset = len(w.data)
+	}
+	line := bytes.Count(w.data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(w.data[:offset], '\n')
+	w.problems = append(w.problems, Problem{Line: line, Column: column, Path: path, Message: message})
+}
+
+// fields : the fields encoding/json decodes into, with the name they have in JSON
+func fields(t reflect.Type) map[string]reflect.StructField {
+	result := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		result[name] = field
+	}
+	return result
+}
+
+func findField(t reflect.Type, key string) (reflect.StructField, bool) {
+	candidates := fields(t)
+	if field, found := candidates[key]; found {
+		return field, true
+	}
+	for name, field := range candidates {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func fieldNames(t reflect.Type) []string {
+	names := make([]string, 0)
+	for name := range fields(t) {
+		names = append(names, name)
+	}
+	return names
+}
+
+// suggest : the closest known name, when the unknown one looks like a typo of it
+func suggest(key string, names []string) string {
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if distance := levenshtein(strings.ToLower(key), strings.ToLower(name)); distance < bestDistance ||
+			distance == bestDistance && best != "" && name < best {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	default:
+		return "integer"
+	}
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
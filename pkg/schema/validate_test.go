@@ -0,0 +1,105 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testInner struct {
+	Enabled bool              `json:"enabled"`
+	Headers map[string]string `json:"headers"`
+}
+
+type testDocument struct {
+	Name    string
+	Count   int
+	Ratio   float64
+	Paths   []string
+	Inner   testInner
+	Pointer *testInner
+	Any     interface{}
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	data := []byte(`{
+  "name": "case-insensitive like encoding/json",
+  "Count": 3,
+  "Ratio": 0.5,
+  "Paths": ["a", "b"],
+  "Inner": {"enabled": true, "headers": {"X-Any": "value"}},
+  "Pointer": null,
+  "Any": {"whatever": [1, 2]}
+}`)
+
+	assert.Empty(t, Validate(data, testDocument{}))
+}
+
+func TestValidateReportsUnknownFieldsWithPosition(t *testing.T) {
+	data := []byte(`{
+  "Nmae": "typo",
+  "Inner": {
+    "enabeld": true,
+    "unknown": {"nested": [1, 2, 3]}
+  },
+  "Pointer": {"other": 1}
+}`)
+
+	problems := Validate(data, testDocument{})
+
+	assert.Equal(t, []Problem{
+		{Line: 2, Column: 3, Path: "", Message: `unknown field "Nmae", did you mean "Name"?`},
+		{Line: 4, Column: 5, Path: "Inner", Message: `unknown field "enabeld", did you mean "enabled"?`},
+		{Line: 5, Column: 5, Path: "Inner", Message: `unknown field "unknown"`},
+		{Line: 7, Column: 15, Path: "Pointer", Message: `unknown field "other"`},
+	}, problems)
+	assert.Equal(t, `4:5: Inner: unknown field "enabeld", did you mean "enabled"?`, problems[1].String())
+}
+
+func TestValidateReportsTypeMismatches(t *testing.T) {
+	data := []byte(`{"Name": 1, "Count": 1.5, "Paths": "a", "Inner": {"enabled": "yes", "headers": {"a": 1}}}`)
+
+	problems := Validate(data, &testDocument{})
+
+	assert.Equal(t, []string{
+		"1:10: Name: expected string, got number",
+		"1:22: Count: expected an integer, got 1.5",
+		"1:36: Paths: expected array, got string",
+		"1:62: Inner.enabled: expected boolean, got string",
+		"1:86: Inner.headers.a: expected string, got number",
+	}, problemStrings(problems))
+}
+
+func TestValidateReportsSyntaxErrors(t *testing.T) {
+	problems := Validate([]byte("{\n  \"Name\": \"a\",\n  \"Count\": 1,\n}"), testDocument{})
+	assert.Len(t, problems, 1)
+	assert.Equal(t, 4, problems[0].Line)
+	assert.Equal(t, 1, problems[0].Column)
+
+	problems = Validate([]byte(""), testDocument{})
+	assert.Equal(t, []string{"1:1: unexpected end of JSON input"}, problemStrings(problems))
+
+	problems = Validate([]byte(`{"Name": "a"`), testDocument{})
+	assert.Equal(t, []string{"1:13: unexpected end of JSON input"}, problemStrings(problems))
+}
+
+func TestGenerate(t *testing.T) {
+	schema := Generate(testDocument{}, "Test")
+
+	assert.Equal(t, "Test", schema["title"])
+	assert.Equal(t, false, schema["additionalProperties"])
+	properties := schema["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "integer"}, properties["Count"])
+	assert.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}, properties["Paths"])
+	inner := properties["Inner"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "boolean"}, inner["enabled"])
+	assert.Equal(t, map[string]interface{}{}, properties["Any"])
+}
+
+func problemStrings(problems []Problem) []string {
+	result := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		result = append(result, problem.String())
+	}
+	return result
+}
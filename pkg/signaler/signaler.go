@@ -1,31 +1,214 @@
 package signaler
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
+// EscalatedExitCode : the exit code to use when the child had to be killed after the termination grace period
+const EscalatedExitCode = 124
+
+// Signaler : forwards signals to a child process
+type Signaler struct {
+	process                *os.Process
+	forwardGracetime       time.Duration
+	terminationGracePeriod time.Duration
+	processGroup           bool
+	forwardedSignals       []os.Signal
+	signalMap              map[os.Signal]os.Signal
+
+	mu        sync.Mutex
+	timer     *time.Timer
+	stopped   bool
+	escalated bool
+}
+
+// Option :
+type Option func(*Signaler)
+
+// WithTerminationGracePeriod : send SIGKILL to the child if it has not exited this long after it was sent a SIGTERM
+// or SIGINT, after the signal map is applied. Zero waits forever.
+func WithTerminationGracePeriod(gracePeriod time.Duration) Option {
+	return func(s *Signaler) {
+		s.terminationGracePeriod = gracePeriod
+	}
+}
+
+// WithProcessGroup : send signals to the process group of the child, and not only the child.
+// The child must be started in its own process group, see SetProcessGroup.
+func WithProcessGroup() Option {
+	return func(s *Signaler) {
+		s.processGroup = true
+	}
+}
+
+// WithForwardedSignals : the signals that are forwarded to the child, instead of DefaultForwardedSignals
+func WithForwardedSignals(signals []os.Signal) Option {
+	return func(s *Signaler) {
+		s.forwardedSignals = signals
+	}
+}
+
+// WithSignalMap : translates a received signal to another signal before it is sent to the child.
+// Signals that are mapped are forwarded, even when they are not in the forwarded signals.
+func WithSignalMap(signalMap map[os.Signal]os.Signal) Option {
+	return func(s *Signaler) {
+		s.signalMap = signalMap
+	}
+}
+
+// SetProcessGroup : start the command in its own process group, with the same id as its pid
+func SetProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
 // Start : Used to forward signals to child processes
-func Start(p *os.Process, forwardGracetime time.Duration) {
+func Start(p *os.Process, forwardGracetime time.Duration, options ...Option) *Signaler {
+	s := newSignaler(p, forwardGracetime, options...)
 	c := make(chan os.Signal, 10)
-	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGUSR1)
-	go forward(c, p, forwardGracetime)
-}
-func forward(signals chan os.Signal, p *os.Process, forwardGracetime time.Duration) {
-	for s := range signals {
-		logrus.Infof("Got %s. Sending to child in %0.0f seconds", s, forwardGracetime.Seconds())
-		if forwardGracetime > 0 {
-			time.Sleep(forwardGracetime)
+	signal.Notify(c, s.notifiedSignals()...)
+	go s.forward(c)
+	return s
+}
+
+func newSignaler(p *os.Process, forwardGracetime time.Duration, options ...Option) *Signaler {
+	s := &Signaler{
+		process:          p,
+		forwardGracetime: forwardGracetime,
+		forwardedSignals: DefaultForwardedSignals,
+		signalMap:        map[os.Signal]os.Signal{},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Stop : the child has exited, so signals are no longer forwarded, and it should not be killed when the
+// termination grace period expires. Radish keeps receiving the signals, so it is not terminated by them while exiting.
+func (s *Signaler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+// Signal : sends the signal to the child, or to its process group
+func (s *Signaler) Signal(sig os.Signal) error {
+	if !s.processGroup {
+		return s.process.Signal(sig)
+	}
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return errors.Errorf("Unsupported signal %s", sig)
+	}
+	return syscall.Kill(-s.process.Pid, sysSig)
+}
+
+// Escalated : true if the child was sent SIGKILL because the termination grace period expired
+func (s *Signaler) Escalated() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.escalated
+}
+
+func (s *Signaler) forward(signals chan os.Signal) {
+	for sig := range signals {
+		if s.isStopped() {
+			logrus.Infof("Got %s after the child exited. Not forwarding", SignalName(sig))
+			continue
+		}
+		translated := s.translate(sig)
+		logrus.Infof("Got %s. Sending %s to child in %0.0f seconds", SignalName(sig), SignalName(translated), s.forwardGracetime.Seconds())
+		if s.forwardGracetime > 0 {
+			time.Sleep(s.forwardGracetime)
 		}
-		logrus.Infof("Sending signal %s to process %d", syscall.SIGTERM, p.Pid)
-		err := p.Signal(s)
+		logrus.Infof("Sending signal %s to %s", SignalName(translated), s.target())
+		err := s.Signal(translated)
 		if err != nil {
 			logrus.Errorf("Error sending signal %s", err)
 		}
+		if translated == syscall.SIGTERM || translated == syscall.SIGINT {
+			s.startTerminationTimer()
+		}
+
+	}
+}
+
+func (s *Signaler) isStopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopped
+}
+
+func (s *Signaler) translate(sig os.Signal) os.Signal {
+	if translated, exists := s.signalMap[sig]; exists {
+		return translated
+	}
+	return sig
+}
+
+func (s *Signaler) notifiedSignals() []os.Signal {
+	signals := append([]os.Signal{}, s.forwardedSignals...)
+	for from := range s.signalMap {
+		if !containsSignal(signals, from) {
+			signals = append(signals, from)
+		}
+	}
+	return signals
+}
+
+func containsSignal(signals []os.Signal, sig os.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// startTerminationTimer : the grace period is counted from when the first termination signal is sent to the child, so
+// the child gets all of it after RADISH_SIGNAL_FORWARD_DELAY
+func (s *Signaler) startTerminationTimer() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.terminationGracePeriod <= 0 || s.timer != nil || s.stopped {
+		return
+	}
+	s.timer = time.AfterFunc(s.terminationGracePeriod, s.kill)
+}
+
+func (s *Signaler) kill() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	logrus.Warnf("Process %d did not terminate within %s. Sending SIGKILL to %s", s.process.Pid, s.terminationGracePeriod, s.target())
+	err := s.Signal(syscall.SIGKILL)
+	if err != nil {
+		logrus.Errorf("Error sending signal %s", err)
+		return
+	}
+	s.escalated = true
+}
 
+func (s *Signaler) target() string {
+	if s.processGroup {
+		return fmt.Sprintf("process group %d", s.process.Pid)
 	}
+	return fmt.Sprintf("process %d", s.process.Pid)
 }
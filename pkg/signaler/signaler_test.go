@@ -0,0 +1,175 @@
+package signaler
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// startStubChild : a child that ignores SIGTERM, like a JVM hanging in a shutdown hook
+func startStubChild(t *testing.T) *exec.Cmd {
+	return startChildIgnoring(t, "TERM")
+}
+
+func startChildIgnoring(t *testing.T, signals string) *exec.Cmd {
+	cmd := exec.Command("sh", "-c", `trap "" `+signals+`; echo ready; exec sleep 30`)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	// Wait until the trap is installed
+	ready, err := bufio.NewReader(stdout).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ready\n", ready)
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+	})
+	return cmd
+}
+
+func TestEscalatesToSigkillAfterTerminationGracePeriod(t *testing.T) {
+	cmd := startStubChild(t)
+	s := newSignaler(cmd.Process, 0, WithTerminationGracePeriod(200*time.Millisecond))
+
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	err := cmd.Wait()
+	s.Stop()
+
+	status := err.(*exec.ExitError).Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGKILL, status.Signal())
+	assert.True(t, s.Escalated())
+}
+
+func TestTerminationGracePeriodStartsWhenTheSignalIsForwarded(t *testing.T) {
+	cmd := startStubChild(t)
+	s := newSignaler(cmd.Process, 300*time.Millisecond, WithTerminationGracePeriod(200*time.Millisecond))
+
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	start := time.Now()
+	signals <- syscall.SIGTERM
+
+	_ = cmd.Wait()
+	s.Stop()
+
+	assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+	assert.True(t, s.Escalated())
+}
+
+func TestDoesNotEscalateWhenChildExits(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	assert.NoError(t, cmd.Start())
+	s := newSignaler(cmd.Process, 0, WithTerminationGracePeriod(200*time.Millisecond))
+
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	err := cmd.Wait()
+	s.Stop()
+	time.Sleep(300 * time.Millisecond)
+
+	status := err.(*exec.ExitError).Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGTERM, status.Signal())
+	assert.False(t, s.Escalated())
+}
+
+func TestDoesNotEscalateWithoutTerminationGracePeriod(t *testing.T) {
+	cmd := startStubChild(t)
+	s := newSignaler(cmd.Process, 0)
+
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	time.Sleep(300 * time.Millisecond)
+
+	assert.False(t, s.Escalated())
+	assert.NoError(t, cmd.Process.Signal(syscall.Signal(0)))
+}
+
+func TestTerminationGracePeriodFollowsTheTranslatedSignal(t *testing.T) {
+	cmd := startChildIgnoring(t, "QUIT")
+	s := newSignaler(cmd.Process, 0, WithTerminationGracePeriod(200*time.Millisecond),
+		WithSignalMap(map[os.Signal]os.Signal{syscall.SIGTERM: syscall.SIGQUIT}))
+
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	time.Sleep(400 * time.Millisecond)
+
+	assert.False(t, s.Escalated())
+	assert.NoError(t, cmd.Process.Signal(syscall.Signal(0)))
+
+	cmd = startStubChild(t)
+	s = newSignaler(cmd.Process, 0, WithTerminationGracePeriod(200*time.Millisecond),
+		WithSignalMap(map[os.Signal]os.Signal{syscall.SIGQUIT: syscall.SIGTERM}))
+
+	signals = make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGQUIT
+
+	err := cmd.Wait()
+	s.Stop()
+
+	status := err.(*exec.ExitError).Sys().(syscall.WaitStatus)
+	assert.Equal(t, syscall.SIGKILL, status.Signal())
+	assert.True(t, s.Escalated())
+}
+
+func TestForwardsToProcessGroup(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `(trap "echo grandchild terminated; exit 0" TERM; echo ready; while true; do sleep 0.05; done) & wait`)
+	SetProcessGroup(cmd)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	})
+	output := bufio.NewReader(stdout)
+	ready, err := output.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ready\n", ready)
+
+	s := newSignaler(cmd.Process, 0, WithProcessGroup())
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	terminated, err := output.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "grandchild terminated\n", terminated)
+	_ = cmd.Wait()
+}
+
+func TestForwardsTranslatedSignal(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `trap "echo got INT; exit 0" INT; trap "echo got TERM; exit 0" TERM; echo ready; while true; do sleep 0.05; done`)
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	assert.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+	})
+	output := bufio.NewReader(stdout)
+	ready, err := output.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "ready\n", ready)
+
+	s := newSignaler(cmd.Process, 0, WithSignalMap(map[os.Signal]os.Signal{syscall.SIGTERM: syscall.SIGINT}))
+	signals := make(chan os.Signal, 1)
+	go s.forward(signals)
+	signals <- syscall.SIGTERM
+
+	received, err := output.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "got INT\n", received)
+	_ = cmd.Wait()
+}
@@ -0,0 +1,96 @@
+package signaler
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultForwardedSignals : the signals radish forwards to the child when nothing else is configured
+var DefaultForwardedSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGUSR1}
+
+var signalNames = map[string]syscall.Signal{
+	"SIGHUP":   syscall.SIGHUP,
+	"SIGINT":   syscall.SIGINT,
+	"SIGQUIT":  syscall.SIGQUIT,
+	"SIGABRT":  syscall.SIGABRT,
+	"SIGKILL":  syscall.SIGKILL,
+	"SIGUSR1":  syscall.SIGUSR1,
+	"SIGUSR2":  syscall.SIGUSR2,
+	"SIGPIPE":  syscall.SIGPIPE,
+	"SIGALRM":  syscall.SIGALRM,
+	"SIGTERM":  syscall.SIGTERM,
+	"SIGCONT":  syscall.SIGCONT,
+	"SIGSTOP":  syscall.SIGSTOP,
+	"SIGTSTP":  syscall.SIGTSTP,
+	"SIGWINCH": syscall.SIGWINCH,
+}
+
+// ParseSignal : parses a signal name like SIGTERM or TERM, in any case, or a signal number like 15
+func ParseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if number, err := strconv.Atoi(name); err == nil && number > 0 {
+		return syscall.Signal(number), nil
+	}
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+	sig, exists := signalNames[name]
+	if !exists {
+		return 0, errors.Errorf("Unknown signal %s", name)
+	}
+	return sig, nil
+}
+
+// ParseSignals : parses a comma separated list of signals, like "SIGINT,SIGTERM"
+func ParseSignals(value string) ([]os.Signal, error) {
+	signals := make([]os.Signal, 0)
+	for _, name := range strings.Split(value, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		sig, err := ParseSignal(name)
+		if err != nil {
+			return nil, err
+		}
+		signals = append(signals, sig)
+	}
+	return signals, nil
+}
+
+// ParseSignalMap : parses a comma separated list of translations, like "SIGTERM=SIGINT,SIGUSR2=SIGQUIT"
+func ParseSignalMap(value string) (map[os.Signal]os.Signal, error) {
+	signalMap := make(map[os.Signal]os.Signal)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		from, to, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, errors.Errorf("Signal mapping %s is not on the form FROM=TO", entry)
+		}
+		fromSignal, err := ParseSignal(from)
+		if err != nil {
+			return nil, err
+		}
+		toSignal, err := ParseSignal(to)
+		if err != nil {
+			return nil, err
+		}
+		signalMap[fromSignal] = toSignal
+	}
+	return signalMap, nil
+}
+
+// SignalName : the name of the signal, like SIGTERM, instead of the description from String()
+func SignalName(sig os.Signal) string {
+	for name, s := range signalNames {
+		if s == sig {
+			return name
+		}
+	}
+	return sig.String()
+}
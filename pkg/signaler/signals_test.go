@@ -0,0 +1,54 @@
+package signaler
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"SIGTERM", "TERM", "sigterm", " term ", "15"} {
+		sig, err := ParseSignal(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, syscall.SIGTERM, sig, name)
+	}
+
+	_, err := ParseSignal("SIGTULLEBALL")
+	assert.EqualError(t, err, "Unknown signal SIGTULLEBALL")
+}
+
+func TestParseSignals(t *testing.T) {
+	signals, err := ParseSignals("SIGINT, TERM,")
+	assert.NoError(t, err)
+	assert.Equal(t, []os.Signal{syscall.SIGINT, syscall.SIGTERM}, signals)
+}
+
+func TestParseSignalMap(t *testing.T) {
+	signalMap, err := ParseSignalMap("SIGTERM=SIGINT, SIGUSR2=SIGQUIT")
+	assert.NoError(t, err)
+	assert.Equal(t, map[os.Signal]os.Signal{
+		syscall.SIGTERM: syscall.SIGINT,
+		syscall.SIGUSR2: syscall.SIGQUIT,
+	}, signalMap)
+
+	_, err = ParseSignalMap("SIGTERM")
+	assert.EqualError(t, err, "Signal mapping SIGTERM is not on the form FROM=TO")
+
+	_, err = ParseSignalMap("SIGTERM=SIGFOO")
+	assert.EqualError(t, err, "Unknown signal SIGFOO")
+}
+
+func TestSignalName(t *testing.T) {
+	assert.Equal(t, "SIGTERM", SignalName(syscall.SIGTERM))
+	assert.Equal(t, "SIGUSR2", SignalName(syscall.SIGUSR2))
+}
+
+func TestNotifiedSignalsIncludeMappedSignals(t *testing.T) {
+	s := newSignaler(nil, 0,
+		WithForwardedSignals([]os.Signal{syscall.SIGTERM}),
+		WithSignalMap(map[os.Signal]os.Signal{syscall.SIGUSR2: syscall.SIGQUIT, syscall.SIGTERM: syscall.SIGINT}))
+
+	assert.ElementsMatch(t, []os.Signal{syscall.SIGTERM, syscall.SIGUSR2}, s.notifiedSignals())
+}
@@ -0,0 +1,177 @@
+package supervisor
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/radish/pkg/reaper"
+	"github.com/skatteetaten/radish/pkg/signaler"
+)
+
+// DefaultDescendantsGracePeriod : how long to wait for the rest of the process tree when the child has exited
+const DefaultDescendantsGracePeriod = 5 * time.Second
+
+// ignoredSignals : radish must survive the signals it forwards, also the ones that are not configured to be forwarded
+var ignoredSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGKILL, syscall.SIGUSR1}
+
+// ExitHandler : rewrites the exit code of the child to the exit code of radish
+type ExitHandler func(exitCode int, pid int) int
+
+// Supervisor : starts a child process as PID 1 would. It forwards signals, reaps descendants,
+// and rewrites the exit code of the child.
+type Supervisor struct {
+	name                   string
+	forwardGracetime       time.Duration
+	signalerOptions        []signaler.Option
+	processGroup           bool
+	descendantsGracePeriod time.Duration
+	exitHandler            ExitHandler
+	afterStart             []func(pid int)
+	beforeExit             []func(exitCode int)
+}
+
+// Option :
+type Option func(*Supervisor)
+
+// WithForwardGracetime : the delay from a signal is received until it is forwarded to the child
+func WithForwardGracetime(forwardGracetime time.Duration) Option {
+	return func(s *Supervisor) {
+		s.forwardGracetime = forwardGracetime
+	}
+}
+
+// WithSignalerOptions :
+func WithSignalerOptions(options ...signaler.Option) Option {
+	return func(s *Supervisor) {
+		s.signalerOptions = append(s.signalerOptions, options...)
+	}
+}
+
+// WithProcessGroup : start the child in its own process group, and signal the whole group
+func WithProcessGroup() Option {
+	return func(s *Supervisor) {
+		s.processGroup = true
+	}
+}
+
+// WithDescendantsGracePeriod :
+func WithDescendantsGracePeriod(gracePeriod time.Duration) Option {
+	return func(s *Supervisor) {
+		s.descendantsGracePeriod = gracePeriod
+	}
+}
+
+// WithExitHandler :
+func WithExitHandler(exitHandler ExitHandler) Option {
+	return func(s *Supervisor) {
+		s.exitHandler = exitHandler
+	}
+}
+
+// WithAfterStart : called with the pid of the child when it has started
+func WithAfterStart(hook func(pid int)) Option {
+	return func(s *Supervisor) {
+		s.afterStart = append(s.afterStart, hook)
+	}
+}
+
+// WithBeforeExit : called with the exit code of the child, before it is rewritten by the exit handler
+func WithBeforeExit(hook func(exitCode int)) Option {
+	return func(s *Supervisor) {
+		s.beforeExit = append(s.beforeExit, hook)
+	}
+}
+
+// New : name is used when logging about the child, e.g. Java or Nginx
+func New(name string, options ...Option) *Supervisor {
+	s := &Supervisor{
+		name:                   name,
+		descendantsGracePeriod: DefaultDescendantsGracePeriod,
+		exitHandler: func(exitCode int, pid int) int {
+			return exitCode
+		},
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Run : starts cmd and waits for it. Returns the exit code radish should exit with.
+func (s *Supervisor) Run(cmd *exec.Cmd) (int, error) {
+	if s.processGroup {
+		signaler.SetProcessGroup(cmd)
+	}
+	// The reaper hands the status of the child back, so it is not lost when the reaper wins the race to reap it
+	reaper.Start()
+	exitStatus, err := reaper.StartProcess(cmd)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Unable to start %s", s.name)
+	}
+	signal.Ignore(ignoredSignals...)
+
+	pid := cmd.Process.Pid
+	logrus.Infof("Started %s with pid=%d", s.name, pid)
+
+	signalerOptions := s.signalerOptions
+	if s.processGroup {
+		signalerOptions = append(signalerOptions, signaler.WithProcessGroup())
+	}
+	sig := signaler.Start(cmd.Process, s.forwardGracetime, signalerOptions...)
+	for _, hook := range s.afterStart {
+		hook(pid)
+	}
+
+	wstatus := <-exitStatus
+	sig.Stop()
+	s.collectDescendants(sig)
+
+	exitCode := exitCodeOf(wstatus)
+	for _, hook := range s.beforeExit {
+		hook(exitCode)
+	}
+	if sig.Escalated() {
+		return signaler.EscalatedExitCode, nil
+	}
+	if exitCode == 0 {
+		logrus.Infof("%s exited successfully", s.name)
+	} else {
+		logrus.Infof("%s terminated with exit code %d", s.name, exitCode)
+	}
+	return s.exitHandler(exitCode, pid), nil
+}
+
+// exitCodeOf : a child killed by a signal gets 128 + the signal number, like in a shell
+func exitCodeOf(wstatus syscall.WaitStatus) int {
+	if wstatus.Signaled() {
+		return 128 + int(wstatus.Signal())
+	}
+	return wstatus.ExitStatus()
+}
+
+// collectDescendants : reaps what is left of the process tree after the child has exited. When the child runs in its
+// own process group, the rest of the group is terminated first, and killed if it does not exit within the grace period.
+func (s *Supervisor) collectDescendants(sig *signaler.Signaler) {
+	if !s.processGroup {
+		if !reaper.Drain(s.descendantsGracePeriod) {
+			logrus.Warnf("Descendants of %s are still running after %s", s.name, s.descendantsGracePeriod)
+		}
+		return
+	}
+	if err := sig.Signal(syscall.SIGTERM); err != nil {
+		// The process group is empty
+		reaper.Drain(s.descendantsGracePeriod)
+		return
+	}
+	if reaper.Drain(s.descendantsGracePeriod) {
+		return
+	}
+	logrus.Warnf("Descendants of %s did not terminate within %s. Sending SIGKILL", s.name, s.descendantsGracePeriod)
+	_ = sig.Signal(syscall.SIGKILL)
+	reaper.Drain(s.descendantsGracePeriod)
+}
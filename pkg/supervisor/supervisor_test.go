@@ -0,0 +1,176 @@
+package supervisor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/skatteetaten/radish/pkg/signaler"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestHelperProcess : not a real test. It is the fake child process started by fakeChild.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("RADISH_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	behaviour, args := args[1], args[2:]
+
+	switch behaviour {
+	case "exit":
+		code, _ := strconv.Atoi(args[0])
+		os.Exit(code)
+	case "exit-on-term":
+		// Exits with 128 + SIGTERM, like the JVM does
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGTERM)
+		fmt.Println("ready")
+		<-c
+		os.Exit(143)
+	case "ignore-term":
+		signal.Ignore(syscall.SIGTERM)
+		fmt.Println("ready")
+		time.Sleep(time.Minute)
+	case "spawn-orphans":
+		// Starts short-lived grandchildren that are orphaned, and reaped by radish, while the child is running
+		count, _ := strconv.Atoi(args[0])
+		for i := 0; i < count; i++ {
+			_ = exec.Command("sh", "-c", "true & true &").Run()
+		}
+		os.Exit(42)
+	case "kill-self":
+		_ = syscall.Kill(os.Getpid(), syscall.SIGKILL)
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+// fakeChild : a child process with the given behaviour, see TestHelperProcess
+func fakeChild(behaviour string, args ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestHelperProcess", "--", behaviour}, args...)...)
+	cmd.Env = append(os.Environ(), "RADISH_WANT_HELPER_PROCESS=1")
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// runUntilReady : runs the supervisor, and sends signal to radish when the child has printed ready
+func runUntilReady(t *testing.T, s *Supervisor, cmd *exec.Cmd, sig syscall.Signal) int {
+	stdout, err := cmd.StdoutPipe()
+	assert.NoError(t, err)
+	go func() {
+		line, err := bufio.NewReader(stdout).ReadString('\n')
+		if err == nil && line == "ready\n" {
+			_ = syscall.Kill(os.Getpid(), sig)
+		}
+	}()
+	exitCode, err := s.Run(cmd)
+	assert.NoError(t, err)
+	return exitCode
+}
+
+func TestRunReturnsExitCodeOfChild(t *testing.T) {
+	exitCode, err := New("fake").Run(fakeChild("exit", "3"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+}
+
+func TestRunRewritesExitCode(t *testing.T) {
+	var handled []int
+	s := New("fake", WithExitHandler(func(exitCode int, pid int) int {
+		handled = append(handled, exitCode, pid)
+		return 0
+	}))
+	cmd := fakeChild("exit", "143")
+
+	exitCode, err := s.Run(cmd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+	assert.Equal(t, []int{143, cmd.Process.Pid}, handled)
+}
+
+func TestRunReturnsSignalAsExitCode(t *testing.T) {
+	exitCode, err := New("fake").Run(fakeChild("kill-self"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 128+int(syscall.SIGKILL), exitCode)
+}
+
+func TestRunForwardsSignals(t *testing.T) {
+	s := New("fake")
+
+	exitCode := runUntilReady(t, s, fakeChild("exit-on-term"), syscall.SIGTERM)
+
+	assert.Equal(t, 143, exitCode)
+}
+
+func TestRunForwardsTranslatedSignals(t *testing.T) {
+	s := New("fake", WithSignalerOptions(
+		signaler.WithSignalMap(map[os.Signal]os.Signal{syscall.SIGUSR2: syscall.SIGTERM})))
+
+	exitCode := runUntilReady(t, s, fakeChild("exit-on-term"), syscall.SIGUSR2)
+
+	assert.Equal(t, 143, exitCode)
+}
+
+func TestRunEscalatesWhenChildIgnoresSigterm(t *testing.T) {
+	s := New("fake", WithSignalerOptions(signaler.WithTerminationGracePeriod(200*time.Millisecond)))
+
+	exitCode := runUntilReady(t, s, fakeChild("ignore-term"), syscall.SIGTERM)
+
+	assert.Equal(t, signaler.EscalatedExitCode, exitCode)
+}
+
+func TestRunInProcessGroup(t *testing.T) {
+	s := New("fake", WithProcessGroup())
+	cmd := fakeChild("exit-on-term")
+
+	exitCode := runUntilReady(t, s, cmd, syscall.SIGTERM)
+
+	assert.Equal(t, 143, exitCode)
+	assert.True(t, cmd.SysProcAttr.Setpgid)
+}
+
+func TestRunCallsHooks(t *testing.T) {
+	var startedPid, exitedWith int
+	s := New("fake",
+		WithAfterStart(func(pid int) {
+			startedPid = pid
+		}),
+		WithBeforeExit(func(exitCode int) {
+			exitedWith = exitCode
+		}))
+	cmd := fakeChild("exit", "5")
+
+	_, err := s.Run(cmd)
+
+	assert.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, startedPid)
+	assert.Equal(t, 5, exitedWith)
+}
+
+func TestRunFailsWhenChildCanNotStart(t *testing.T) {
+	_, err := New("fake").Run(exec.Command("/does/not/exist"))
+
+	assert.Error(t, err)
+}
+
+func TestRunPreservesExitCodeWhileReapingOrphans(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		exitCode, err := New("fake").Run(fakeChild("spawn-orphans", "25"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, exitCode)
+	}
+}
@@ -1,64 +1,251 @@
 package util
 
 import (
-	"github.com/sirupsen/logrus"
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
 	"math"
 	"os"
+	"path"
+	"runtime"
 	"strconv"
 	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCGroupMountPoint = "sys/fs/cgroup"
+	procSelfCgroup          = "proc/self/cgroup"
+	cgroupRootEnv           = "RADISH_CGROUP_ROOT"
 )
 
 // https://www.kernel.org/doc/Documentation/cgroup-v1/memory.txt
 // https://www.kernel.org/doc/Documentation/scheduler/sched-bwc.txt
 const (
-	cfsPeriodUs            = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
-	cfsQuotaUs             = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
-	memAndSwapLimitInBytes = "/sys/fs/cgroup/memory/memory.memsw.limit_in_bytes"
-	memLimitInBytes        = "/sys/fs/cgroup/memory/memory.limit_in_bytes"
+	cfsPeriodUs            = "cpu/cpu.cfs_period_us"
+	cfsQuotaUs             = "cpu/cpu.cfs_quota_us"
+	memAndSwapLimitInBytes = "memory/memory.memsw.limit_in_bytes"
+	memLimitInBytes        = "memory/memory.limit_in_bytes"
+	cpusetCpus             = "cpuset/cpuset.cpus"
+)
+
+// https://www.kernel.org/doc/Documentation/admin-guide/cgroup-v2.rst
+const (
+	cgroupV2Controllers = "cgroup.controllers"
+	cpuMax              = "cpu.max"
+	memoryMax           = "memory.max"
+	memorySwapMax       = "memory.swap.max"
+	cpusetCpusEffective = "cpuset.cpus.effective"
+	cgroupV2Unlimited   = "max"
 )
 
 // CGroupLimits :
 type CGroupLimits struct {
+	// CoresEstimated is the number of cores available, possibly fractional when limited by a cpu quota
+	CoresEstimated float64
+	// MaxCoresEstimated is CoresEstimated rounded up to whole cores
 	MaxCoresEstimated  int
 	MemoryLimitInBytes int
 }
 
+// CGroupLimitsReader : Reads cgroup limits from a filesystem where cgroups are mounted at mountPoint
+type CGroupLimitsReader struct {
+	fsys       fs.FS
+	mountPoint string
+	numCPU     func() int
+}
+
+// NewCGroupLimitsReader : fsys is the root of the filesystem, and both the cgroup mount point and proc/self/cgroup are read relative to it
+func NewCGroupLimitsReader(fsys fs.FS, mountPoint string) CGroupLimitsReader {
+	return CGroupLimitsReader{
+		fsys:       fsys,
+		mountPoint: strings.Trim(path.Clean("/"+mountPoint), "/"),
+		numCPU:     runtime.NumCPU,
+	}
+}
+
+// NewDefaultCGroupLimitsReader : Reads from /sys/fs/cgroup, or from RADISH_CGROUP_ROOT if set
+func NewDefaultCGroupLimitsReader() CGroupLimitsReader {
+	mountPoint := defaultCGroupMountPoint
+	if root, exists := os.LookupEnv(cgroupRootEnv); exists && root != "" {
+		logrus.Debugf("Reading cgroup limits from %s", root)
+		mountPoint = root
+	}
+	return NewCGroupLimitsReader(os.DirFS("/"), mountPoint)
+}
+
 // ReadCGroupLimits :
 func ReadCGroupLimits() CGroupLimits {
+	return NewDefaultCGroupLimitsReader().Read()
+}
 
-	ret := CGroupLimits{}
-	periodUs := readCGroupLimit(cfsPeriodUs)
-	quotaUs := readCGroupLimit(cfsQuotaUs)
-	memLimitInBytes := readCGroupLimit(memLimitInBytes)
-	memAndSwapLimitInBytes := readCGroupLimit(memAndSwapLimitInBytes)
-
-	if periodUs == -1 || quotaUs == -1 {
-		ret.MaxCoresEstimated = -1
-	} else {
-		ret.MaxCoresEstimated = int(math.Ceil(float64(periodUs) / float64(quotaUs)))
+// Read :
+func (r CGroupLimitsReader) Read() CGroupLimits {
+	if r.isCGroupV2() {
+		logrus.Debugf("Found unified cgroup hierarchy (v2) in /%s", r.mountPoint)
+		return r.readCGroupV2Limits(r.findCGroupV2Path())
 	}
+	return r.readCGroupV1Limits()
+}
+
+func (r CGroupLimitsReader) readCGroupV1Limits() CGroupLimits {
+	periodUs := r.readCGroupLimit(cfsPeriodUs)
+	quotaUs := r.readCGroupLimit(cfsQuotaUs)
+	memLimitInBytes := r.readCGroupLimit(memLimitInBytes)
+	memAndSwapLimitInBytes := r.readCGroupLimit(memAndSwapLimitInBytes)
+
+	cpuset := r.readCGroupFile(cpusetCpus)
 
+	ret := r.estimateCores(periodUs, quotaUs, cpuset)
 	if memLimitInBytes > memAndSwapLimitInBytes {
 		ret.MemoryLimitInBytes = memLimitInBytes
 	} else {
 		ret.MemoryLimitInBytes = memAndSwapLimitInBytes
 	}
+	return ret
+}
+
+func (r CGroupLimitsReader) readCGroupV2Limits(cgroupPath string) CGroupLimits {
+	quotaUs, periodUs := -1, -1
+	cpuMaxValues := strings.Fields(r.readCGroupFile(path.Join(cgroupPath, cpuMax)))
+	if len(cpuMaxValues) == 2 {
+		quotaUs = parseCGroupV2Limit(cpuMaxValues[0])
+		periodUs = parseCGroupV2Limit(cpuMaxValues[1])
+	}
 
+	cpuset := r.readCGroupFile(path.Join(cgroupPath, cpusetCpusEffective))
+
+	memoryLimit := parseCGroupV2Limit(r.readCGroupFile(path.Join(cgroupPath, memoryMax)))
+	swapLimit := parseCGroupV2Limit(r.readCGroupFile(path.Join(cgroupPath, memorySwapMax)))
+
+	ret := r.estimateCores(periodUs, quotaUs, cpuset)
+	ret.MemoryLimitInBytes = memoryLimit
+	// memory.swap.max is the swap allowance alone, unlike memory.memsw.limit_in_bytes in v1
+	if memoryLimit > 0 && swapLimit > 0 {
+		ret.MemoryLimitInBytes = memoryLimit + swapLimit
+	}
 	return ret
 }
 
-func readCGroupLimit(cgroupFilePath string) int {
-	dat, err := os.ReadFile(cgroupFilePath)
-	if os.IsNotExist(err) {
-		logrus.Debugf("File %s does not exist", cgroupFilePath)
+// estimateCores : the cores available is the lowest of the cpu quota, the size of the cpuset and the number of cpus.
+// When neither the quota nor the cpuset limits us, the estimate is -1.
+func (r CGroupLimitsReader) estimateCores(periodUs int, quotaUs int, cpuset string) CGroupLimits {
+	numCPU := float64(r.numCPU())
+	cores := numCPU
+	if periodUs > 0 && quotaUs > 0 {
+		cores = math.Min(cores, float64(quotaUs)/float64(periodUs))
+	}
+	if cpusetSize := parseCpusetSize(cpuset); cpusetSize > 0 {
+		cores = math.Min(cores, float64(cpusetSize))
+	}
+	if cores >= numCPU {
+		return CGroupLimits{
+			CoresEstimated:    -1,
+			MaxCoresEstimated: -1,
+		}
+	}
+	return CGroupLimits{
+		CoresEstimated:    cores,
+		MaxCoresEstimated: int(math.Max(1, math.Ceil(cores))),
+	}
+}
+
+// parseCpusetSize : counts the cpus in a cpuset list on the form "0-3,6,8-9". Returns -1 when it can not be parsed.
+func parseCpusetSize(cpuset string) int {
+	if cpuset == "" {
 		return -1
 	}
+	size := 0
+	for _, cpuRange := range strings.Split(cpuset, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(cpuRange), "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			logrus.Errorf("Could not parse cpuset %s because of: %s", cpuset, err)
+			return -1
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil || last < first {
+				logrus.Errorf("Could not parse cpuset %s", cpuset)
+				return -1
+			}
+		}
+		size += last - first + 1
+	}
+	return size
+}
+
+// isCGroupV2 : only the unified hierarchy has cgroup.controllers in the root of the mount.
+// In hybrid mode it is mounted at <root>/unified, and the controllers we read are still v1.
+func (r CGroupLimitsReader) isCGroupV2() bool {
+	_, err := fs.Stat(r.fsys, path.Join(r.mountPoint, cgroupV2Controllers))
+	return err == nil
+}
+
+// findCGroupV2Path : the v2 entry in /proc/self/cgroup has the form "0::<path>".
+// Falls back to the root of the mount when the path is not visible, e.g. with a private cgroup namespace.
+func (r CGroupLimitsReader) findCGroupV2Path() string {
+	dat, err := fs.ReadFile(r.fsys, procSelfCgroup)
 	if err != nil {
-		logrus.Errorf("Could not read %s because of: %s, defaulting to -1", cgroupFilePath, err)
+		logrus.Debugf("Could not read /%s: %s. Using cgroup root", procSelfCgroup, err)
+		return "."
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(dat))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "0::") {
+			continue
+		}
+		cgroupPath := strings.Trim(path.Clean(strings.TrimPrefix(line, "0::")), "/")
+		if cgroupPath == "" {
+			return "."
+		}
+		if _, err := fs.Stat(r.fsys, path.Join(r.mountPoint, cgroupPath)); err != nil {
+			logrus.Debugf("Cgroup /%s is not visible in /%s. Using cgroup root", cgroupPath, r.mountPoint)
+			return "."
+		}
+		return cgroupPath
+	}
+	return "."
+}
+
+func (r CGroupLimitsReader) readCGroupFile(name string) string {
+	cgroupFilePath := path.Join(r.mountPoint, name)
+	dat, err := fs.ReadFile(r.fsys, cgroupFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logrus.Debugf("File /%s does not exist", cgroupFilePath)
+		return ""
+	}
+	if err != nil {
+		logrus.Errorf("Could not read /%s because of: %s", cgroupFilePath, err)
+		return ""
+	}
+	return strings.TrimSpace(string(dat))
+}
+
+func parseCGroupV2Limit(value string) int {
+	if value == "" || value == cgroupV2Unlimited {
+		return -1
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logrus.Errorf("Could not parse %s because of: %s defaulting to -1", value, err)
+		return -1
+	}
+	return parsed
+}
+
+func (r CGroupLimitsReader) readCGroupLimit(name string) int {
+	dat := r.readCGroupFile(name)
+	if dat == "" {
 		return -1
 	}
 
-	parsed, err := strconv.Atoi(strings.TrimSpace(string(dat)))
+	parsed, err := strconv.Atoi(dat)
 	if err != nil {
 		logrus.Errorf("Could not parse %s because of: %s defaulting to -1", dat, err)
 		return -1
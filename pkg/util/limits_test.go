@@ -0,0 +1,135 @@
+package util
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFixtureReader(fsys fs.FS, mountPoint string) CGroupLimitsReader {
+	reader := NewCGroupLimitsReader(fsys, mountPoint)
+	reader.numCPU = func() int {
+		return 8
+	}
+	return reader
+}
+
+func readFixture(layout string) CGroupLimits {
+	return newFixtureReader(os.DirFS("testdata/cgroup/"+layout), defaultCGroupMountPoint).Read()
+}
+
+func TestReadCGroupV1Limits(t *testing.T) {
+	limits := readFixture("v1")
+
+	assert.Equal(t, 2147483648, limits.MemoryLimitInBytes)
+	assert.Equal(t, 2.0, limits.CoresEstimated)
+	assert.Equal(t, 2, limits.MaxCoresEstimated)
+}
+
+func TestReadCGroupV1WithoutLimits(t *testing.T) {
+	limits := readFixture("v1-unlimited")
+
+	assert.False(t, limits.HasCoreLimit())
+	assert.Equal(t, 1024, limits.MemoryFractionInMB(4))
+}
+
+func TestReadCGroupV2Limits(t *testing.T) {
+	limits := readFixture("v2")
+
+	assert.Equal(t, 1073741824, limits.MemoryLimitInBytes)
+	assert.Equal(t, 1.5, limits.CoresEstimated)
+	assert.Equal(t, 2, limits.MaxCoresEstimated)
+}
+
+func TestReadCGroupV2LimitsFromOwnCgroup(t *testing.T) {
+	limits := readFixture("v2-nested")
+
+	assert.Equal(t, 1073741824, limits.MemoryLimitInBytes)
+	// The cpuset of two cpus is lower than the quota of four
+	assert.Equal(t, 2.0, limits.CoresEstimated)
+	assert.Equal(t, 2, limits.MaxCoresEstimated)
+}
+
+func TestReadCGroupV2TreatsMaxAsUnlimited(t *testing.T) {
+	limits := readFixture("v2-unlimited")
+
+	assert.False(t, limits.HasMemoryLimit())
+	assert.False(t, limits.HasCoreLimit())
+}
+
+func TestReadCGroupHybridUsesV1Controllers(t *testing.T) {
+	limits := readFixture("hybrid")
+
+	assert.Equal(t, 4294967296, limits.MemoryLimitInBytes)
+	assert.Equal(t, 4, limits.MaxCoresEstimated)
+}
+
+func TestReadCGroupV2FallsBackToRootWhenCgroupIsNotVisible(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory\n")},
+		"sys/fs/cgroup/memory.max":         {Data: []byte("1073741824\n")},
+		"proc/self/cgroup":                 {Data: []byte("0::/system.slice/containerd.service\n")},
+	}
+
+	limits := newFixtureReader(fsys, defaultCGroupMountPoint).Read()
+
+	assert.Equal(t, 1073741824, limits.MemoryLimitInBytes)
+}
+
+func TestReadCGroupWithoutCgroups(t *testing.T) {
+	limits := newFixtureReader(fstest.MapFS{}, defaultCGroupMountPoint).Read()
+
+	assert.False(t, limits.HasMemoryLimit())
+	assert.False(t, limits.HasCoreLimit())
+}
+
+func TestReadCGroupFromCustomMountPoint(t *testing.T) {
+	limits := newFixtureReader(os.DirFS("testdata/cgroup/custom-mount"), "/host/cgroup").Read()
+
+	assert.Equal(t, 268435456, limits.MemoryLimitInBytes)
+	assert.Equal(t, 1, limits.MaxCoresEstimated)
+}
+
+func TestFractionalCpuQuotaIsRoundedUp(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sys/fs/cgroup/cgroup.controllers": {Data: []byte("cpu memory\n")},
+		"sys/fs/cgroup/cpu.max":            {Data: []byte("20000 100000\n")},
+		"proc/self/cgroup":                 {Data: []byte("0::/\n")},
+	}
+
+	limits := newFixtureReader(fsys, defaultCGroupMountPoint).Read()
+
+	assert.Equal(t, 0.2, limits.CoresEstimated)
+	assert.Equal(t, 1, limits.MaxCoresEstimated)
+}
+
+func TestCpuLimitIsCappedByNumberOfCpus(t *testing.T) {
+	reader := newFixtureReader(os.DirFS("testdata/cgroup/v2-nested"), defaultCGroupMountPoint)
+	reader.numCPU = func() int {
+		return 1
+	}
+
+	limits := reader.Read()
+
+	assert.False(t, limits.HasCoreLimit())
+}
+
+func TestParseCpusetSize(t *testing.T) {
+	assert.Equal(t, 1, parseCpusetSize("0"))
+	assert.Equal(t, 4, parseCpusetSize("0-3"))
+	assert.Equal(t, 6, parseCpusetSize("0-3,6,8"))
+	assert.Equal(t, -1, parseCpusetSize(""))
+	assert.Equal(t, -1, parseCpusetSize("3-1"))
+	assert.Equal(t, -1, parseCpusetSize("a-b"))
+}
+
+func TestDefaultReaderHonoursCGroupRootEnv(t *testing.T) {
+	t.Setenv("RADISH_CGROUP_ROOT", "/host/cgroup")
+
+	reader := NewDefaultCGroupLimitsReader()
+
+	assert.Equal(t, "host/cgroup", reader.mountPoint)
+}
@@ -48,7 +48,7 @@ func NewFileWriter(targetFolder string) FileWriter {
 		}
 		fileToWriteTo, err := os.Create(fp)
 		if err != nil {
-			return errors.Wrapf(err, "Error creating %+t", elem)
+			return errors.Wrapf(err, "Error creating %v", elem)
 		}
 		defer func(fileToWriteTo *os.File) {
 			_ = fileToWriteTo.Close()
@@ -59,7 +59,7 @@ func NewFileWriter(targetFolder string) FileWriter {
 		}
 		err = fileToWriteTo.Sync()
 		if err != nil {
-			return errors.Wrapf(err, "Error writing %+t", elem)
+			return errors.Wrapf(err, "Error writing %v", elem)
 		}
 		return nil
 	}